@@ -23,11 +23,21 @@
 package option
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
+	"net/url"
+	"os"
 	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -39,8 +49,16 @@ import (
 	fakeclient "github.com/yshngg/kle/pkg/client/fake"
 	"github.com/yshngg/kle/pkg/leaderelection"
 	"github.com/yshngg/kle/pkg/middleware"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apiserver/pkg/server/healthz"
 	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	k8sleaderelection "k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/record"
 	componentbaseconfig "k8s.io/component-base/config"
 	componentbaseoptions "k8s.io/component-base/config/options"
 	"k8s.io/klog/v2"
@@ -51,33 +69,192 @@ const ServerShutdownTimeout = 10 * time.Second
 type KLEServer struct {
 	Addr   string
 	DryRun bool
+	// DryRunFaults are injected into the Lease requests of the fake
+	// clientset used in dry run mode.
+	DryRunFaults fakeclient.Faults
 
-	LeaderElection   componentbaseconfig.LeaderElectionConfiguration
-	ClientConnection componentbaseconfig.ClientConnectionConfiguration
+	LeaderElection        componentbaseconfig.LeaderElectionConfiguration
+	LeaderElectionOptions leaderelection.Options
+	ClientConnection      componentbaseconfig.ClientConnectionConfiguration
+
+	// ReleaseToken authenticates requests to ReleasePath, read from
+	// ReleaseTokenFile if empty. The route is not served without a token.
+	ReleaseToken     string
+	ReleaseTokenFile string
+
+	// PreconditionURLs are HTTP preconditions by name, each passes while its
+	// URL answers GET with a 2xx status.
+	PreconditionURLs map[string]string
+
+	// QuorumClusters are the <kubeconfig>[@<context>] clusters a quorum
+	// election spans, leader election stays in a single cluster when empty.
+	QuorumClusters []string
+
+	// LeaderPodLabel is the key=value label kept on the Pod while it leads.
+	LeaderPodLabel string
+
+	// LeaderForward decides how followers answer requests only the leader
+	// serves.
+	LeaderForward ForwardMode
 }
 
 func NewKLEServer() *KLEServer {
 	return &KLEServer{
-		Addr:           ":2190",
-		LeaderElection: *leaderelection.DefaultLeaderElectionConfig(),
+		Addr:                  ":2190",
+		LeaderElection:        *leaderelection.DefaultLeaderElectionConfig(),
+		LeaderElectionOptions: *leaderelection.DefaultOptions(),
+		LeaderForward:         ForwardModeProxy,
+		LeaderPodLabel:        "kle.io/role=leader",
 	}
 }
 
+// Apply validates the configuration. Every problem found is returned, as an
+// aggregate of field errors.
 func (ks *KLEServer) Apply() error {
-	return nil
+	var allErrs field.ErrorList
+	// An empty address listens on the HTTP port, as http.Server does.
+	if ks.Addr == "" {
+		ks.Addr = ":80"
+	}
+	allErrs = append(allErrs, validateAddr(ks.Addr, field.NewPath("addr"))...)
+	allErrs = append(allErrs, validateClientConnection(&ks.ClientConnection, field.NewPath("clientConnection"))...)
+	allErrs = append(allErrs, leaderelection.ValidateLeaderElectionConfiguration(&ks.LeaderElection, field.NewPath("leaderElection"))...)
+	allErrs = append(allErrs, ks.applyQuorum(field.NewPath("quorumClusters"))...)
+	allErrs = append(allErrs, ks.LeaderElectionOptions.Validate(&ks.LeaderElection, field.NewPath("leaderElectionOptions"))...)
+	if ks.LeaderElection.LeaderElect && ks.LeaderPodLabel != "" {
+		if _, _, err := parseLabel(ks.LeaderPodLabel); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("leaderPodLabel"), ks.LeaderPodLabel, err.Error()))
+		}
+	}
+	for name, u := range ks.PreconditionURLs {
+		if parsed, err := url.Parse(u); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("preconditionURLs").Key(name), u, "must be an absolute URL"))
+		}
+	}
+	allErrs = append(allErrs, validateFaults(ks.DryRunFaults, field.NewPath("dryRunFaults"))...)
+	if ks.ReleaseToken == "" && ks.ReleaseTokenFile != "" {
+		if _, err := os.Stat(ks.ReleaseTokenFile); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("releaseTokenFile"), ks.ReleaseTokenFile, err.Error()))
+		}
+	}
+	return allErrs.ToAggregate()
+}
+
+// applyQuorum names the clusters of a quorum election in
+// LeaderElectionOptions.Quorum, their clients are created by Run.
+func (ks *KLEServer) applyQuorum(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if !ks.LeaderElection.LeaderElect || len(ks.QuorumClusters) == 0 {
+		return allErrs
+	}
+	quorum := &leaderelection.Quorum{}
+	for i, s := range ks.QuorumClusters {
+		cluster, err := client.ParseCluster(s)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), s, err.Error()))
+			continue
+		}
+		name, err := cluster.Name()
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), s, err.Error()))
+			continue
+		}
+		quorum.Clusters = append(quorum.Clusters, leaderelection.QuorumCluster{Name: name})
+	}
+	ks.LeaderElectionOptions.Quorum = quorum
+	return allErrs
+}
+
+// validateFaults validates the faults injected in dry run mode.
+func validateFaults(faults fakeclient.Faults, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if faults.Latency < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("latency"), faults.Latency.String(), "must not be negative"))
+	}
+	if faults.ErrorRate < 0 || faults.ErrorRate > 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("errorRate"), faults.ErrorRate, "must be between 0 and 1"))
+	}
+	if faults.ConflictRate < 0 || faults.ConflictRate > 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("conflictRate"), faults.ConflictRate, "must be between 0 and 1"))
+	}
+	if faults.PartitionAfter < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("partitionAfter"), faults.PartitionAfter.String(), "must not be negative"))
+	}
+	return allErrs
+}
+
+// validateAddr validates a host:port address to listen on, the host may be
+// empty.
+func validateAddr(addr string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return append(allErrs, field.Invalid(fldPath, addr, err.Error()))
+	}
+	if n, err := strconv.ParseUint(port, 10, 16); err != nil && port != "" {
+		allErrs = append(allErrs, field.Invalid(fldPath, addr, "port must be a number between 0 and 65535"))
+	} else if port == "" || n == 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath, addr, "port must be set"))
+	}
+	return allErrs
+}
+
+func validateClientConnection(cc *componentbaseconfig.ClientConnectionConfiguration, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if cc.QPS < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("qps"), cc.QPS, "must not be negative"))
+	}
+	if cc.Burst < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("burst"), cc.Burst, "must not be negative"))
+	}
+	if cc.Kubeconfig != "" {
+		if _, err := os.Stat(cc.Kubeconfig); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("kubeconfig"), cc.Kubeconfig, err.Error()))
+		}
+	}
+	return allErrs
 }
 
 // AddFlags adds flags for a specific KLEServer to the specified FlagSet
 func (ks *KLEServer) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&ks.Addr, "addr", ks.Addr, "The address kel server binds to.")
 
+	fs.StringVar(&ks.LeaderElectionOptions.AdvertiseAddress, "advertise-address", ks.LeaderElectionOptions.AdvertiseAddress, "The host:port the other replicas reach this one on. Defaults to the port of --addr on the POD_IP the Downward API sets, or on the hostname.")
+	fs.StringVar(&ks.LeaderPodLabel, "leader-elect-pod-label", ks.LeaderPodLabel, "The key=value label kept on the Pod named by POD_NAMESPACE and POD_NAME while it leads, so that a Service can select the leader. Empty disables it. This is only applicable if leader election is enabled.")
+	fs.Var(&ks.LeaderForward, "leader-forward", "How followers answer requests only the leader serves. 'proxy' proxies them to the leader, 'redirect' redirects the client to the leader with a 307 and 'none' answers with 404. This is only applicable if leader election is enabled.")
 	fs.BoolVar(&ks.DryRun, "dry-run", ks.DryRun, "Execute kle in dry run mode.")
+	fs.DurationVar(&ks.DryRunFaults.Latency, "dry-run-inject-latency", ks.DryRunFaults.Latency, "Delay every Lease request by this long. This is only applicable in dry run mode.")
+	fs.Float64Var(&ks.DryRunFaults.ErrorRate, "dry-run-error-rate", ks.DryRunFaults.ErrorRate, "The probability, from 0 to 1, for a Lease request to fail with an internal error. This is only applicable in dry run mode.")
+	fs.Float64Var(&ks.DryRunFaults.ConflictRate, "dry-run-conflict-rate", ks.DryRunFaults.ConflictRate, "The probability, from 0 to 1, for a Lease write to fail with a conflict. This is only applicable in dry run mode.")
+	fs.DurationVar(&ks.DryRunFaults.PartitionAfter, "dry-run-partition-after", ks.DryRunFaults.PartitionAfter, "Fail every Lease request once kle has run for this long, as if the API server became unreachable. 0 never does. This is only applicable in dry run mode.")
 	fs.StringVar(&ks.ClientConnection.Kubeconfig, "kubeconfig", ks.ClientConnection.Kubeconfig, "File with kube configuration. Deprecated, use client-connection-kubeconfig instead.")
 	fs.StringVar(&ks.ClientConnection.Kubeconfig, "client-connection-kubeconfig", ks.ClientConnection.Kubeconfig, "File path to kube configuration for interacting with kubernetes apiserver.")
 	fs.Float32Var(&ks.ClientConnection.QPS, "client-connection-qps", ks.ClientConnection.QPS, "QPS to use for interacting with kubernetes apiserver.")
 	fs.Int32Var(&ks.ClientConnection.Burst, "client-connection-burst", ks.ClientConnection.Burst, "Burst to use for interacting with kubernetes apiserver.")
 
 	componentbaseoptions.BindLeaderElectionFlags(&ks.LeaderElection, fs)
+	fs.Lookup("leader-elect-resource-lock").Usage = fmt.Sprintf("The type of resource object that is used for locking during leader election. Supported options are %s. "+
+		"'configmapsleases' and 'endpointsleases' lock on both objects and are only meant for migrating off 'configmaps' and 'endpoints' to 'leases'. "+
+		"'file' locks on the local file named by --leader-elect-resource-name, so that processes on one host can elect a leader without a cluster. "+
+		"'memory' locks on a record kept in memory, so that candidates within one process can elect a leader.",
+		"'"+strings.Join(leaderelection.ResourceLockTypes(), "', '")+"'")
+	fs.StringVar(&ks.LeaderElectionOptions.Identity, "leader-elect-identity", ks.LeaderElectionOptions.Identity, "The identity this candidate holds leases under. Defaults to the identity in --leader-elect-identity-file, then to <POD_NAMESPACE>/<POD_NAME> when the Downward API sets them, then to a unique <hostname>_<uuid>. A candidate restarted under the same identity takes its lease back right away. This is only applicable if leader election is enabled.")
+	fs.StringVar(&ks.LeaderElectionOptions.IdentityFile, "leader-elect-identity-file", ks.LeaderElectionOptions.IdentityFile, "File the identity is read from, and persisted to if it does not exist yet, so that it survives restarts. This is only applicable if leader election is enabled.")
+	fs.DurationVar(&ks.LeaderElectionOptions.HealthzTimeout, "leader-elect-healthz-timeout", ks.LeaderElectionOptions.HealthzTimeout, "How long the leader may fail to renew its lease, beyond the lease duration, before /healthz reports it unhealthy. This is only applicable if leader election is enabled.")
+	fs.IntVar(&ks.LeaderElectionOptions.Priority, "leader-elect-priority", ks.LeaderElectionOptions.Priority, "The priority this candidate publishes. Candidates defer to live candidates of a higher priority, and a leader steps down once one has been around for the stabilization window. This is only applicable if leader election is enabled.")
+	fs.DurationVar(&ks.LeaderElectionOptions.PriorityStabilizationWindow, "leader-elect-priority-stabilization-window", ks.LeaderElectionOptions.PriorityStabilizationWindow, "How long a candidate of a higher priority must be around before the leader steps down for it. This is only applicable if leader election is enabled.")
+	fs.IntVar(&ks.LeaderElectionOptions.Shards, "leader-elect-shards", ks.LeaderElectionOptions.Shards, "The number of leases, called <resource-name>-<index>, spread across the candidates so that each leads a fair share of them. 0 disables sharding. This is only applicable if leader election is enabled.")
+	fs.BoolVar(&ks.LeaderElectionOptions.Coordinated, "leader-elect-coordinated", ks.LeaderElectionOptions.Coordinated, "Advertise this candidate as a coordination.k8s.io LeaseCandidate and follow the leader the API server picks, instead of racing for the lease. Falls back to the classic election where the API is not served. Only applicable with the leases resource lock.")
+	fs.StringVar(&ks.LeaderElectionOptions.BinaryVersion, "leader-elect-binary-version", ks.LeaderElectionOptions.BinaryVersion, "The version advertised by the LeaseCandidate and published to the other candidates, defaults to the version of this build. This is only applicable if leader election is enabled.")
+	fs.BoolVar(&ks.LeaderElectionOptions.UpgradeAware, "leader-elect-upgrade-aware", ks.LeaderElectionOptions.UpgradeAware, "Defer to live candidates running a newer --leader-elect-binary-version, and step down for them while leading, so that leadership only moves forward during a rolling update. This is only applicable if leader election is enabled.")
+	fs.DurationVar(&ks.LeaderElectionOptions.MaxClockSkew, "leader-elect-max-clock-skew", ks.LeaderElectionOptions.MaxClockSkew, "How far the local clock may be off the API server's, as told by the Date of its responses, for this candidate to campaign and for /healthz to report it healthy. 0 disables the limit. This is only applicable if leader election is enabled.")
+	fs.StringArrayVar(&ks.QuorumClusters, "leader-elect-quorum-cluster", ks.QuorumClusters, "A <kubeconfig>[@<context>] cluster to hold the lease in, the current context of the kubeconfig by default. May be repeated. Leadership then requires holding the lease in a strict majority of the clusters, and the leader steps down as soon as it fails to renew a majority. This is only applicable if leader election is enabled.")
+	fs.StringToStringVar(&ks.PreconditionURLs, "leader-elect-precondition-url", ks.PreconditionURLs, "A name=url precondition, which passes while the URL answers GET with a 2xx status. May be repeated. A candidate only campaigns while every precondition passes, and a leader whose preconditions fail for the grace period steps down. This is only applicable if leader election is enabled.")
+	fs.DurationVar(&ks.LeaderElectionOptions.PreconditionGracePeriod, "leader-elect-precondition-grace-period", ks.LeaderElectionOptions.PreconditionGracePeriod, "How long the preconditions of the leader may fail before it steps down. This is only applicable if leader election is enabled.")
+	fs.DurationVar(&ks.LeaderElectionOptions.ReleaseCoolDown, "leader-elect-release-cool-down", ks.LeaderElectionOptions.ReleaseCoolDown, "How long a replica stays out of the race after it was asked to release its leadership. This is only applicable if leader election is enabled.")
+	fs.StringVar(&ks.ReleaseToken, "leader-release-token", ks.ReleaseToken, "The bearer token that authenticates requests to "+ReleasePath+". The route is not served without a token.")
+	fs.StringVar(&ks.ReleaseTokenFile, "leader-release-token-file", ks.ReleaseTokenFile, "File holding the bearer token that authenticates requests to "+ReleasePath+", used if --leader-release-token is empty.")
+	fs.Var(&ks.LeaderElectionOptions.OnLoss, "leader-elect-on-loss", "What to do once leadership is lost. 'recampaign' campaigns again, 'exit' exits with status 0 and 'exit-error' exits with a distinct non-zero status. This is only applicable if leader election is enabled.")
 }
 
 func (ks *KLEServer) Run(ctx context.Context) (err error) {
@@ -86,7 +263,19 @@ func (ks *KLEServer) Run(ctx context.Context) (err error) {
 
 	mux := http.NewServeMux()
 	http.Handle("/", mux)
-	healthz.InstallHandler(mux)
+	var checks []healthz.HealthChecker
+	if ks.LeaderElection.LeaderElect {
+		// Reports a leader whose renew loop is wedged as unhealthy, so that
+		// the kubelet restarts it.
+		ks.LeaderElectionOptions.WatchDog = k8sleaderelection.NewLeaderHealthzAdaptor(ks.LeaderElectionOptions.HealthzTimeout)
+		checks = append(checks, ks.LeaderElectionOptions.WatchDog)
+	}
+	skew := &leaderelection.ClockSkew{Max: ks.LeaderElectionOptions.MaxClockSkew}
+	if ks.LeaderElection.LeaderElect {
+		ks.LeaderElectionOptions.ClockSkew = skew
+		checks = append(checks, skew)
+	}
+	healthz.InstallHandler(mux, checks...)
 	healthz.InstallLivezHandler(mux)
 
 	go func() {
@@ -96,33 +285,189 @@ func (ks *KLEServer) Run(ctx context.Context) (err error) {
 		}
 	}()
 
-	run := func() {
-		healthz.InstallReadyzHandler(mux)
+	registry := prometheus.NewRegistry()
+	ks.LeaderElectionOptions.Metrics = leaderelection.NewMetrics(registry)
+	skew.Metrics = ks.LeaderElectionOptions.Metrics
+
+	if ks.LeaderElection.LeaderElect {
+		ks.LeaderElectionOptions.FencingStatus = &leaderelection.FencingStatus{}
+		mux.Handle("/leader/fencing-token", ks.LeaderElectionOptions.FencingStatus)
+	}
+	if ks.LeaderElectionOptions.Shards > 0 {
+		ks.LeaderElectionOptions.ShardStatus = &leaderelection.ShardStatus{}
+		mux.Handle("/leader/shards", ks.LeaderElectionOptions.ShardStatus)
+	}
+
+	// Only a leader is ready, and only a leader serves the leader-only
+	// routes, followers send them to the leader. Both are installed once so
+	// that a new term does not register them again. leading counts the
+	// terms running, one per owned shard.
+	var leading atomic.Int32
+	readyChecks := []healthz.HealthChecker{healthz.NamedCheck("leader", func(_ *http.Request) error {
+		if leading.Load() == 0 {
+			return errors.New("not leading")
+		}
+		return nil
+	})}
+	if ks.LeaderElection.LeaderElect && len(ks.PreconditionURLs) > 0 {
+		preconditions := &leaderelection.Preconditions{GracePeriod: ks.LeaderElectionOptions.PreconditionGracePeriod}
+		names := make([]string, 0, len(ks.PreconditionURLs))
+		for name := range ks.PreconditionURLs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			preconditions.Register(httpPrecondition(name, ks.PreconditionURLs[name]))
+		}
+		ks.LeaderElectionOptions.Preconditions = preconditions
+		readyChecks = append(readyChecks, preconditions.Checks()...)
+	}
+	if quorum := ks.LeaderElectionOptions.Quorum; ks.LeaderElection.LeaderElect && quorum != nil {
+		readyChecks = append(readyChecks, quorum.Checks()...)
+	}
+	healthz.InstallReadyzHandler(mux, readyChecks...)
+	if ks.LeaderElectionOptions.AdvertiseAddress == "" {
+		ks.LeaderElectionOptions.AdvertiseAddress = advertiseAddress(ks.Addr)
+	}
+	router := &leaderRouter{
+		leading: &leading,
+		mode:    ks.LeaderForward,
+		self:    ks.LeaderElectionOptions.AdvertiseAddress,
+		forwarded: func(handlerName string, h http.Handler) http.Handler {
+			return middleware.New(registry, nil).WrapHandler(handlerName+" forwarded", h)
+		},
+	}
+	if ks.LeaderElection.LeaderElect && ks.LeaderForward != ForwardModeNone {
+		ks.LeaderElectionOptions.Leaders = &leaderelection.Leaders{}
+		router.leaders = ks.LeaderElectionOptions.Leaders
+	}
+	registerHandlers(registry, router)
+
+	if ks.LeaderElection.LeaderElect {
+		token, err := ReadToken(ks.ReleaseToken, ks.ReleaseTokenFile)
+		if err != nil {
+			return err
+		}
+		if token != "" {
+			ks.LeaderElectionOptions.Release = &leaderelection.Release{}
+			mux.Handle(ReleasePath, releaseHandler(ks.LeaderElectionOptions.Release, token, ks.LeaderElectionOptions.ReleaseCoolDown, &leading))
+		} else {
+			klog.V(1).Infof("No release token, not serving %s", ReleasePath)
+		}
+	}
+
+	var labeler *podLabeler
+	run := func(ctx context.Context) {
+		if leading.Add(1) == 1 && labeler != nil {
+			labeler.setLeading(true)
+		}
+		// The term ends as soon as leadership is lost, not once the
+		// workload returns, so that no two Pods carry the label meanwhile.
+		var once sync.Once
+		stop := func() {
+			once.Do(func() {
+				if leading.Add(-1) == 0 && labeler != nil {
+					labeler.setLeading(false)
+				}
+			})
+		}
+		context.AfterFunc(ctx, stop)
+		defer stop()
 		run(ctx)
 	}
 
+	// The Pod kle runs in, as named by the Downward API.
+	pod := types.NamespacedName{
+		Namespace: cmp.Or(os.Getenv(leaderelection.PodNamespaceEnv), ks.LeaderElection.ResourceNamespace),
+		Name:      os.Getenv(leaderelection.PodNameEnv),
+	}
+
 	var kubeClient clientset.Interface
-	if ks.DryRun {
+	switch {
+	case ks.DryRun:
 		klog.Warning("dry run mode")
-		kubeClient, err = fakeclient.Kubernetes()
+		// Make up the Pod, so that there is one to label.
+		if pod.Name == "" {
+			pod.Name = "kle"
+		}
+		if ks.DryRunFaults.Enabled() {
+			klog.Warningf("Injecting faults into the Lease requests: %s", ks.DryRunFaults)
+		}
+		kubeClient, err = fakeclient.KubernetesWithFaults(ks.DryRunFaults, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name}})
 		if err != nil {
 			return fmt.Errorf("create kubernetes client, err: %w", err)
 		}
-	} else {
-		kubeClient, err = client.Kubernetes(ks.ClientConnection)
+	case !ks.LeaderElection.LeaderElect || leaderelection.UsesKubernetes(ks.LeaderElection.ResourceLock):
+		kubeClient, err = client.Kubernetes(ks.ClientConnection, skew.ObserveDate)
 		if err != nil {
 			return fmt.Errorf("create kubernetes client, err: %w", err)
 		}
+	default:
+		// The lock does not live on the API server, so neither does kle.
+	}
+
+	if quorum := ks.LeaderElectionOptions.Quorum; ks.LeaderElection.LeaderElect && quorum != nil {
+		for i, s := range ks.QuorumClusters {
+			cluster, err := client.ParseCluster(s)
+			if err != nil {
+				return err
+			}
+			if ks.DryRun {
+				// Every cluster gets a fake client of its own.
+				quorum.Clusters[i].Client, err = fakeclient.KubernetesWithFaults(ks.DryRunFaults)
+			} else {
+				quorum.Clusters[i].Client, err = client.KubernetesForCluster(ks.ClientConnection, cluster, skew.ObserveDate)
+			}
+			if err != nil {
+				return fmt.Errorf("create kubernetes client for cluster %s, err: %w", quorum.Clusters[i].Name, err)
+			}
+		}
+	}
+
+	if ks.LeaderElection.LeaderElect && kubeClient != nil {
+		// The broadcaster outlives the signal, so that the events of the
+		// release and loss of leadership on shutdown still go out. It stops
+		// once the election returns.
+		broadcaster := record.NewBroadcaster(record.WithContext(context.WithoutCancel(ctx)))
+		broadcaster.StartStructuredLogging(3)
+		broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+		defer broadcaster.Shutdown()
+		ks.LeaderElectionOptions.EventRecorder = broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{
+			Component: "kle",
+			Host:      os.Getenv(leaderelection.NodeNameEnv),
+		})
+	}
+
+	if ks.LeaderElection.LeaderElect && ks.LeaderPodLabel != "" {
+		key, value, err := parseLabel(ks.LeaderPodLabel)
+		if err != nil {
+			return err
+		}
+		switch {
+		case kubeClient == nil:
+			klog.V(1).Info("Not labelling the leader pod, the lock does not live on the API server")
+		case pod.Name == "":
+			klog.V(1).Infof("Not labelling the leader pod, %s is not set", leaderelection.PodNameEnv)
+		default:
+			klog.Infof("Labelling the leader pod requires RBAC on pods: get, patch")
+			labeler = newPodLabeler(kubeClient, pod.Namespace, pod.Name, key, value)
+			labelCtx, cancel := context.WithCancel(ctx)
+			done := labeler.run(labelCtx, ks.LeaderElection.RetryPeriod.Duration)
+			defer func() {
+				cancel()
+				<-done
+			}()
+		}
 	}
 
 	if ks.LeaderElection.LeaderElect {
-		if err = leaderelection.NewLeaderElection(run, kubeClient, &ks.LeaderElection, ctx); err != nil {
-			return fmt.Errorf("create leader election, err: %w", err)
+		if err = leaderelection.NewLeaderElection(run, kubeClient, &ks.LeaderElection, &ks.LeaderElectionOptions, ctx); err != nil {
+			return fmt.Errorf("leader election, err: %w", err)
 		}
 		return nil
 	}
 
-	run()
+	run(ctx)
 	return nil
 }
 
@@ -153,7 +498,9 @@ func httpServer(ctx context.Context, addr string) error {
 	return err
 }
 
-func run(ctx context.Context) {
+// registerHandlers registers the HTTP routes. /metrics is served by every
+// replica, the others go through router.
+func registerHandlers(registry *prometheus.Registry, router *leaderRouter) {
 	pingCounter := prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Name: "ping_request_count",
@@ -161,8 +508,6 @@ func run(ctx context.Context) {
 		},
 	)
 
-	registry := prometheus.NewRegistry()
-
 	// Add go runtime metrics and process collectors.
 	registry.MustRegister(
 		pingCounter,
@@ -170,14 +515,14 @@ func run(ctx context.Context) {
 		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 	)
 
-	http.HandleFunc("/ping", func(w http.ResponseWriter, req *http.Request) {
+	http.Handle("/ping", router.route("/ping", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		pingCounter.Inc()
 		_, err := fmt.Fprintf(w, "pong")
 		if err != nil {
 			klog.Errorf("failed to write response: %v", err)
 			return
 		}
-	})
+	})))
 
 	// Expose /metrics HTTP endpoint using the created custom registry.
 	http.Handle(
@@ -188,13 +533,23 @@ func run(ctx context.Context) {
 				promhttp.HandlerOpts{},
 			)),
 	)
+}
 
+func run(ctx context.Context) {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
+	if token, ok := leaderelection.FencingTokenFromContext(ctx); ok {
+		klog.Infof("Leading with fencing token %s", token)
+	}
+
 	for {
 		select {
 		case <-ticker.C:
+			if shard, ok := leaderelection.ShardFromContext(ctx); ok {
+				klog.Infof("tick shard %d of %v...", shard, leaderelection.OwnedShardsFromContext(ctx))
+				continue
+			}
 			klog.Info("tick...")
 		case <-ctx.Done():
 			return
@@ -0,0 +1,161 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package option
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		wantErr bool
+	}{
+		{addr: ":2190"},
+		{addr: "127.0.0.1:2190"},
+		{addr: "[::1]:2190"},
+		{addr: "localhost:65535"},
+		{addr: "2190", wantErr: true},
+		{addr: ":", wantErr: true},
+		{addr: ":0", wantErr: true},
+		{addr: ":65536", wantErr: true},
+		{addr: ":http", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			errs := validateAddr(tt.addr, field.NewPath("addr"))
+			if (len(errs) > 0) != tt.wantErr {
+				t.Errorf("validateAddr(%q) = %v, want error %v", tt.addr, errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKLEServerApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(ks *KLEServer)
+		wantFields []string
+		wantAddr   string
+	}{
+		{name: "defaults", modify: func(ks *KLEServer) {}, wantAddr: ":2190"},
+		{name: "empty address", modify: func(ks *KLEServer) { ks.Addr = "" }, wantAddr: ":80"},
+		{name: "invalid address", modify: func(ks *KLEServer) { ks.Addr = "localhost" }, wantFields: []string{"addr"}},
+		{
+			name: "client connection",
+			modify: func(ks *KLEServer) {
+				ks.ClientConnection.QPS = -1
+				ks.ClientConnection.Burst = -1
+				ks.ClientConnection.Kubeconfig = "/does/not/exist"
+			},
+			wantFields: []string{"clientConnection.qps", "clientConnection.burst", "clientConnection.kubeconfig"},
+		},
+		{
+			name: "leader election",
+			modify: func(ks *KLEServer) {
+				ks.LeaderElection.LeaderElect = true
+				ks.LeaderElection.RenewDeadline.Duration = ks.LeaderElection.LeaseDuration.Duration
+				ks.LeaderElection.ResourceLock = "nope"
+			},
+			wantFields: []string{"leaderElection.renewDeadline", "leaderElection.resourceLock"},
+		},
+		{
+			name: "leader election disabled",
+			modify: func(ks *KLEServer) {
+				ks.LeaderElection.LeaderElect = false
+				ks.LeaderElection.ResourceLock = "nope"
+				ks.LeaderElectionOptions.HealthzTimeout = 0
+				ks.LeaderPodLabel = "not a label"
+			},
+		},
+		{
+			name: "leader election options",
+			modify: func(ks *KLEServer) {
+				ks.LeaderElection.LeaderElect = true
+				ks.LeaderElectionOptions.OnLoss = "panic"
+				ks.LeaderElectionOptions.HealthzTimeout = 0
+				ks.LeaderElectionOptions.ReleaseCoolDown = -time.Second
+			},
+			wantFields: []string{"leaderElectionOptions.onLoss", "leaderElectionOptions.healthzTimeout", "leaderElectionOptions.releaseCoolDown"},
+		},
+		{
+			name: "leader pod label",
+			modify: func(ks *KLEServer) {
+				ks.LeaderElection.LeaderElect = true
+				ks.LeaderPodLabel = "not a label"
+			},
+			wantFields: []string{"leaderPodLabel"},
+		},
+		{
+			name:       "precondition url",
+			modify:     func(ks *KLEServer) { ks.PreconditionURLs = map[string]string{"db": "db:5432"} },
+			wantFields: []string{"preconditionURLs[db]"},
+		},
+		{
+			name: "faults",
+			modify: func(ks *KLEServer) {
+				ks.DryRunFaults.Latency = -time.Second
+				ks.DryRunFaults.ErrorRate = 2
+				ks.DryRunFaults.ConflictRate = -1
+				ks.DryRunFaults.PartitionAfter = -time.Second
+			},
+			wantFields: []string{"dryRunFaults.latency", "dryRunFaults.errorRate", "dryRunFaults.conflictRate", "dryRunFaults.partitionAfter"},
+		},
+		{
+			name:       "release token file",
+			modify:     func(ks *KLEServer) { ks.ReleaseTokenFile = "/does/not/exist" },
+			wantFields: []string{"releaseTokenFile"},
+		},
+		{
+			name: "release token set",
+			modify: func(ks *KLEServer) {
+				ks.ReleaseToken = "secret"
+				ks.ReleaseTokenFile = "/does/not/exist"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := NewKLEServer()
+			tt.modify(ks)
+
+			var fields []string
+			if err := ks.Apply(); err != nil {
+				for _, err := range err.(utilerrors.Aggregate).Errors() {
+					fields = append(fields, err.(*field.Error).Field)
+				}
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("Apply() errors for %v, want %v", fields, tt.wantFields)
+			}
+			if tt.wantAddr != "" && ks.Addr != tt.wantAddr {
+				t.Errorf("Addr = %q, want %q", ks.Addr, tt.wantAddr)
+			}
+		})
+	}
+}
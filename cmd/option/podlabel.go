@@ -0,0 +1,160 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package option
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// podLabelTimeout bounds a single patch of the Pod.
+const podLabelTimeout = 5 * time.Second
+
+// parseLabel parses a key=value label.
+func parseLabel(s string) (key, value string, err error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return "", "", fmt.Errorf("label %q must be key=value", s)
+	}
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return "", "", fmt.Errorf("label key %q is invalid: %s", key, strings.Join(errs, "; "))
+	}
+	if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+		return "", "", fmt.Errorf("label value %q is invalid: %s", value, strings.Join(errs, "; "))
+	}
+	return key, value, nil
+}
+
+// podLabeler keeps a label on the Pod kle runs in while it leads, so that a
+// Service can select the leader. The label is cleared when kle starts,
+// in case a previous run left it behind, and when it stops.
+type podLabeler struct {
+	client     clientset.Interface
+	namespace  string
+	name       string
+	key, value string
+
+	mu      sync.Mutex
+	leading bool
+	notify  chan struct{}
+}
+
+func newPodLabeler(client clientset.Interface, namespace, name, key, value string) *podLabeler {
+	return &podLabeler{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		key:       key,
+		value:     value,
+		notify:    make(chan struct{}, 1),
+	}
+}
+
+// setLeading asks for the label to be set or cleared. Patches are made in
+// the background, only the latest request counts.
+func (l *podLabeler) setLeading(leading bool) {
+	l.mu.Lock()
+	l.leading = leading
+	l.mu.Unlock()
+	select {
+	case l.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run patches the Pod until ctx is done, then clears the label. The returned
+// channel is closed once it has.
+func (l *podLabeler) run(ctx context.Context, retryPeriod time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Clean up after a previous run, unless leading already.
+		select {
+		case l.notify <- struct{}{}:
+		default:
+		}
+
+		var retry <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				ctx, cancel := context.WithTimeout(context.Background(), podLabelTimeout)
+				defer cancel()
+				if err := l.patch(ctx, false); err != nil {
+					klog.Errorf("Failed to clear label %s of pod %s/%s, err: %v", l.key, l.namespace, l.name, err)
+				}
+				return
+			case <-l.notify:
+			case <-retry:
+			}
+
+			l.mu.Lock()
+			leading := l.leading
+			l.mu.Unlock()
+			retry = nil
+			if err := l.patch(ctx, leading); err != nil {
+				klog.Errorf("Failed to label pod %s/%s, retrying in %v, err: %v", l.namespace, l.name, retryPeriod, err)
+				retry = time.After(retryPeriod)
+			}
+		}
+	}()
+	return done
+}
+
+func (l *podLabeler) patch(ctx context.Context, leading bool) error {
+	ctx, cancel := context.WithTimeout(ctx, podLabelTimeout)
+	defer cancel()
+
+	// A null value removes the label in a merge patch.
+	var value *string
+	if leading {
+		value = &l.value
+	}
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"labels": map[string]*string{l.key: value},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := l.client.CoreV1().Pods(l.namespace).Patch(ctx, l.name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return err
+	}
+	if leading {
+		klog.V(1).Infof("Labelled pod %s/%s with %s=%s", l.namespace, l.name, l.key, l.value)
+	} else {
+		klog.V(1).Infof("Cleared label %s of pod %s/%s", l.key, l.namespace, l.name)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package option
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseLabel(t *testing.T) {
+	tests := []struct {
+		label     string
+		wantKey   string
+		wantValue string
+		wantErr   bool
+	}{
+		{label: "kle.io/leader=true", wantKey: "kle.io/leader", wantValue: "true"},
+		{label: "leader=", wantKey: "leader"},
+		{label: "leader", wantErr: true},
+		{label: "kle.io/=true", wantErr: true},
+		{label: "leader=not valid", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			key, value, err := parseLabel(tt.label)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLabel(%q) err = %v, want error %v", tt.label, err, tt.wantErr)
+			}
+			if key != tt.wantKey || value != tt.wantValue {
+				t.Errorf("parseLabel(%q) = %q, %q, want %q, %q", tt.label, key, value, tt.wantKey, tt.wantValue)
+			}
+		})
+	}
+}
+
+// TestPodLabeler checks that the label is cleared of a previous run, set
+// while leading, and cleared again once leadership is lost or kle stops.
+func TestPodLabeler(t *testing.T) {
+	client := fakeclientset.NewClientset(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "demo",
+		Name:      "kle-0",
+		Labels:    map[string]string{"app": "kle", "kle.io/leader": "true"},
+	}})
+	labels := func() map[string]string {
+		t.Helper()
+		pod, err := client.CoreV1().Pods("demo").Get(context.Background(), "kle-0", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pod.Labels
+	}
+	labelled := func(want bool) {
+		t.Helper()
+		waitFor(t, 5*time.Second, func() bool {
+			_, ok := labels()["kle.io/leader"]
+			return ok == want
+		})
+		if labels()["app"] != "kle" {
+			t.Errorf("labels = %v, other labels were changed", labels())
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	labeler := newPodLabeler(client, "demo", "kle-0", "kle.io/leader", "true")
+	done := labeler.run(ctx, 100*time.Millisecond)
+	labelled(false)
+
+	labeler.setLeading(true)
+	labelled(true)
+	labeler.setLeading(false)
+	labelled(false)
+
+	labeler.setLeading(true)
+	labelled(true)
+	cancel()
+	<-done
+	labelled(false)
+}
@@ -0,0 +1,90 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package option
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/yshngg/kle/pkg/leaderelection"
+	"k8s.io/klog/v2"
+)
+
+// ReleasePath is where a replica can be asked to release its leadership.
+const ReleasePath = "/leader/release"
+
+// CoolDownParam overrides the cool-down of a single release request.
+const CoolDownParam = "cool-down"
+
+// ReadToken returns token, or the content of file if token is empty.
+func ReadToken(token, file string) (string, error) {
+	if token != "" || file == "" {
+		return token, nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("read token file, err: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// releaseHandler asks release to move leadership off this replica. Callers
+// authenticate with the bearer token.
+func releaseHandler(release *leaderelection.Release, token string, coolDown time.Duration, leading *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		got, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		d := coolDown
+		if v := req.URL.Query().Get(CoolDownParam); v != "" {
+			var err error
+			if d, err = time.ParseDuration(v); err != nil || d < 0 {
+				http.Error(w, fmt.Sprintf("invalid %s %q", CoolDownParam, v), http.StatusBadRequest)
+				return
+			}
+		}
+
+		wasLeading := leading.Load() > 0
+		release.Request(d)
+		msg := fmt.Sprintf("released leadership, cooling down for %v\n", d)
+		if !wasLeading {
+			msg = fmt.Sprintf("not leading, cooling down for %v\n", d)
+		}
+		if _, err := fmt.Fprint(w, msg); err != nil {
+			klog.Errorf("failed to write response: %v", err)
+		}
+	})
+}
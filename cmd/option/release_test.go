@@ -0,0 +1,103 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package option
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yshngg/kle/pkg/leaderelection"
+)
+
+func TestReleaseHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		query         string
+		leading       int32
+		wantStatus    int
+		wantBody      string
+		// wantCoolDown is the cool-down expected to be requested, none if
+		// zero.
+		wantCoolDown time.Duration
+	}{
+		{name: "get", method: http.MethodGet, authorization: "Bearer secret", wantStatus: http.StatusMethodNotAllowed},
+		{name: "no token", method: http.MethodPost, wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", method: http.MethodPost, authorization: "Bearer wrong", wantStatus: http.StatusUnauthorized},
+		{name: "not a bearer token", method: http.MethodPost, authorization: "secret", wantStatus: http.StatusUnauthorized},
+		{
+			name: "invalid cool-down", method: http.MethodPost, authorization: "Bearer secret", query: "?cool-down=soon",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative cool-down", method: http.MethodPost, authorization: "Bearer secret", query: "?cool-down=-1s",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "leading", method: http.MethodPost, authorization: "Bearer secret", leading: 1,
+			wantStatus: http.StatusOK, wantBody: "released leadership", wantCoolDown: time.Minute,
+		},
+		{
+			name: "not leading", method: http.MethodPost, authorization: "Bearer secret",
+			wantStatus: http.StatusOK, wantBody: "not leading", wantCoolDown: time.Minute,
+		},
+		{
+			name: "cool-down override", method: http.MethodPost, authorization: "Bearer secret", query: "?cool-down=1h", leading: 1,
+			wantStatus: http.StatusOK, wantBody: "cooling down for 1h0m0s", wantCoolDown: time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := &leaderelection.Release{}
+			var leading atomic.Int32
+			leading.Store(tt.leading)
+			handler := releaseHandler(release, "secret", time.Minute, &leading)
+
+			req := httptest.NewRequest(tt.method, ReleasePath+tt.query, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+			left, cooling := release.CoolingDown()
+			if cooling != (tt.wantCoolDown > 0) {
+				t.Fatalf("cooling down = %v, want %v", cooling, tt.wantCoolDown > 0)
+			}
+			if cooling && (left > tt.wantCoolDown || left < tt.wantCoolDown-time.Minute/2) {
+				t.Errorf("cooling down for %v, want %v", left, tt.wantCoolDown)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package option
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"sync/atomic"
+
+	"github.com/yshngg/kle/pkg/leaderelection"
+	"k8s.io/klog/v2"
+)
+
+// ForwardMode decides how a follower answers requests only the leader
+// serves.
+type ForwardMode string
+
+const (
+	// ForwardModeProxy reverse-proxies the request to the leader.
+	ForwardModeProxy ForwardMode = "proxy"
+	// ForwardModeRedirect redirects the client to the leader with a 307.
+	ForwardModeRedirect ForwardMode = "redirect"
+	// ForwardModeNone answers with 404.
+	ForwardModeNone ForwardMode = "none"
+)
+
+// String implements pflag.Value.
+func (m *ForwardMode) String() string {
+	return string(*m)
+}
+
+// Set implements pflag.Value.
+func (m *ForwardMode) Set(s string) error {
+	switch ForwardMode(s) {
+	case ForwardModeProxy, ForwardModeRedirect, ForwardModeNone:
+		*m = ForwardMode(s)
+		return nil
+	default:
+		return fmt.Errorf("unknown forward mode %q, must be one of %q, %q or %q", s, ForwardModeProxy, ForwardModeRedirect, ForwardModeNone)
+	}
+}
+
+// Type implements pflag.Value.
+func (m *ForwardMode) Type() string {
+	return "string"
+}
+
+// forwardedHeader marks requests a follower sent to the leader, so that
+// they are not sent on again while leadership moves.
+const forwardedHeader = "X-KLE-Forwarded-By"
+
+// advertiseAddress returns where the candidate serving on addr can be
+// reached by the others: the Pod IP the Downward API sets, or the hostname.
+func advertiseAddress(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return ""
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = os.Getenv(PodIPEnv)
+	}
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	if host == "" {
+		return ""
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// PodIPEnv is the Downward API environment variable holding the Pod IP.
+const PodIPEnv = "POD_IP"
+
+// leaderRouter serves requests only the leader serves: itself while
+// leading, otherwise by sending them to the leader the way mode says. self
+// is where this replica is served. forwarded, when set, wraps the handler
+// that sends the requests of the named route on.
+type leaderRouter struct {
+	leading   *atomic.Int32
+	leaders   *leaderelection.Leaders
+	mode      ForwardMode
+	self      string
+	forwarded func(handlerName string, h http.Handler) http.Handler
+}
+
+func (r *leaderRouter) route(handlerName string, h http.Handler) http.Handler {
+	forward := http.Handler(http.HandlerFunc(r.forward))
+	if r.forwarded != nil {
+		forward = r.forwarded(handlerName, forward)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.leading.Load() > 0 {
+			h.ServeHTTP(w, req)
+			return
+		}
+		if r.mode == ForwardModeNone || r.leaders == nil {
+			http.NotFound(w, req)
+			return
+		}
+		forward.ServeHTTP(w, req)
+	})
+}
+
+func (r *leaderRouter) forward(w http.ResponseWriter, req *http.Request) {
+	if by := req.Header.Get(forwardedHeader); by != "" {
+		// The leader that was sent this request is not leading anymore.
+		http.Error(w, fmt.Sprintf("not leading, request already forwarded by %s", by), http.StatusServiceUnavailable)
+		return
+	}
+	leader, address, ok := r.leaders.Leader()
+	if !ok {
+		msg := "no leader"
+		if leader != "" {
+			msg = fmt.Sprintf("leader %s did not publish its address", leader)
+		}
+		http.Error(w, msg, http.StatusServiceUnavailable)
+		return
+	}
+	target := &url.URL{Scheme: "http", Host: address}
+
+	if r.mode == ForwardModeRedirect {
+		u := *req.URL
+		u.Scheme, u.Host = target.Scheme, target.Host
+		http.Redirect(w, req, u.String(), http.StatusTemporaryRedirect)
+		return
+	}
+
+	klog.V(4).Infof("Proxying %s %s to leader %s at %s", req.Method, req.URL.Path, leader, address)
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			pr.Out.Header.Set(forwardedHeader, cmp.Or(r.self, "follower"))
+		},
+	}
+	proxy.ServeHTTP(w, req)
+}
@@ -0,0 +1,166 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package option
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yshngg/kle/pkg/leaderelection"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+// TestLeaderRouter elects a leader on the memory lock, and checks how a
+// follower answers the requests only the leader serves.
+func TestLeaderRouter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs leader elections")
+	}
+	leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = io.WriteString(w, "leader served "+req.URL.Path+" forwarded by "+req.Header.Get(forwardedHeader))
+	}))
+	defer leader.Close()
+	leaderAddress := strings.TrimPrefix(leader.URL, "http://")
+
+	config := &componentbaseconfig.LeaderElectionConfiguration{
+		LeaderElect:       true,
+		LeaseDuration:     metav1.Duration{Duration: 2 * time.Second},
+		RenewDeadline:     metav1.Duration{Duration: 1500 * time.Millisecond},
+		RetryPeriod:       metav1.Duration{Duration: 100 * time.Millisecond},
+		ResourceLock:      leaderelection.MemoryResourceLock,
+		ResourceName:      "router",
+		ResourceNamespace: "test",
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	start := func(identity, address string, leaders *leaderelection.Leaders) {
+		opts := leaderelection.DefaultOptions()
+		opts.Identity = identity
+		opts.AdvertiseAddress = address
+		opts.Leaders = leaders
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run := func(ctx context.Context) { <-ctx.Done() }
+			if err := leaderelection.NewLeaderElection(run, nil, config, opts, ctx); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	start("router-a", leaderAddress, nil)
+	waitFor(t, 5*time.Second, func() bool {
+		record, ok := leaderelection.DefaultMemoryStore().Record("test", "router")
+		return ok && record.HolderIdentity == "router-a"
+	})
+	leaders := &leaderelection.Leaders{}
+	start("router-b", "10.0.0.2:2190", leaders)
+	waitFor(t, 5*time.Second, func() bool {
+		_, _, ok := leaders.Leader()
+		return ok
+	})
+
+	tests := []struct {
+		name    string
+		mode    ForwardMode
+		leading int32
+		// noLeaders routes without tracking the leaders.
+		noLeaders   bool
+		forwardedBy string
+		wantStatus  int
+		wantBody    string
+		wantHeader  string
+	}{
+		{name: "leading", mode: ForwardModeProxy, leading: 1, wantStatus: http.StatusOK, wantBody: "served locally"},
+		{name: "proxy", mode: ForwardModeProxy, wantStatus: http.StatusOK, wantBody: "leader served /leader/work forwarded by 10.0.0.2:2190"},
+		{name: "redirect", mode: ForwardModeRedirect, wantStatus: http.StatusTemporaryRedirect, wantHeader: leader.URL + "/leader/work?x=1"},
+		{name: "none", mode: ForwardModeNone, wantStatus: http.StatusNotFound},
+		{name: "no leaders", mode: ForwardModeProxy, noLeaders: true, wantStatus: http.StatusNotFound},
+		{
+			name: "already forwarded", mode: ForwardModeProxy, forwardedBy: "10.0.0.3:2190",
+			wantStatus: http.StatusServiceUnavailable, wantBody: "already forwarded by 10.0.0.3:2190",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var leading atomic.Int32
+			leading.Store(tt.leading)
+			router := &leaderRouter{leading: &leading, leaders: leaders, mode: tt.mode, self: "10.0.0.2:2190"}
+			if tt.noLeaders {
+				router.leaders = nil
+			}
+			handler := router.route("work", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = io.WriteString(w, "served locally")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/leader/work?x=1", nil)
+			if tt.forwardedBy != "" {
+				req.Header.Set(forwardedHeader, tt.forwardedBy)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantHeader {
+				t.Errorf("Location = %q, want %q", got, tt.wantHeader)
+			}
+		})
+	}
+
+	// Without a leader publishing its address, there is nowhere to send
+	// the requests to.
+	var leading atomic.Int32
+	router := &leaderRouter{leading: &leading, leaders: &leaderelection.Leaders{}, mode: ForwardModeProxy}
+	rec := httptest.NewRecorder()
+	router.route("work", http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/leader/work", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status without a leader = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %v", timeout)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
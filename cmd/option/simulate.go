@@ -0,0 +1,587 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package option
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/pflag"
+	fakeclient "github.com/yshngg/kle/pkg/client/fake"
+	"github.com/yshngg/kle/pkg/leaderelection"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/watch"
+	clientset "k8s.io/client-go/kubernetes"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	componentbaseconfig "k8s.io/component-base/config"
+	componentbaseoptions "k8s.io/component-base/config/options"
+	"k8s.io/klog/v2"
+)
+
+// errKilled fails the Lease requests of a killed candidate.
+var errKilled = errors.New("candidate killed")
+
+// KLESimulation runs candidates against a shared fake clientset while
+// killing, pausing and restarting them, then reports how the election
+// behaved.
+type KLESimulation struct {
+	Candidates int
+	Duration   time.Duration
+	// Interval is the mean time between two disruptions.
+	Interval time.Duration
+	// Seed seeds the choice of disruptions, 0 picks a random seed.
+	Seed   uint64
+	Output string
+	Faults fakeclient.Faults
+
+	LeaderElection componentbaseconfig.LeaderElectionConfiguration
+}
+
+func NewKLESimulation() *KLESimulation {
+	config := leaderelection.DefaultLeaderElectionConfig()
+	config.LeaderElect = true
+	// Shorter than the defaults, so that a simulation sees many terms.
+	config.LeaseDuration = metav1.Duration{Duration: 4 * time.Second}
+	config.RenewDeadline = metav1.Duration{Duration: 3 * time.Second}
+	config.RetryPeriod = metav1.Duration{Duration: time.Second}
+	return &KLESimulation{
+		Candidates:     3,
+		Duration:       time.Minute,
+		Interval:       5 * time.Second,
+		Output:         OutputTable,
+		LeaderElection: *config,
+	}
+}
+
+// AddFlags adds flags for a specific KLESimulation to the specified FlagSet
+func (ks *KLESimulation) AddFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&ks.Candidates, "candidates", ks.Candidates, "The number of candidates to run.")
+	fs.DurationVar(&ks.Duration, "duration", ks.Duration, "How long to run the simulation for.")
+	fs.DurationVar(&ks.Interval, "interval", ks.Interval, "The mean time between two disruptions, each of which kills, pauses or restarts a candidate.")
+	fs.Uint64Var(&ks.Seed, "seed", ks.Seed, "The seed of the choice of disruptions, 0 picks a random one. Timings still vary between runs.")
+	fs.StringVarP(&ks.Output, "output", "o", ks.Output, "Output format of the report, one of '"+OutputTable+"' or '"+OutputJSON+"'.")
+	fs.DurationVar(&ks.Faults.Latency, "inject-latency", ks.Faults.Latency, "Delay every Lease request by this long.")
+	fs.Float64Var(&ks.Faults.ErrorRate, "error-rate", ks.Faults.ErrorRate, "The probability, from 0 to 1, for a Lease request to fail with an internal error.")
+	fs.Float64Var(&ks.Faults.ConflictRate, "conflict-rate", ks.Faults.ConflictRate, "The probability, from 0 to 1, for a Lease write to fail with a conflict.")
+
+	componentbaseoptions.BindLeaderElectionFlags(&ks.LeaderElection, fs)
+}
+
+// Apply validates the simulation. Every problem found is returned, as an
+// aggregate of field errors.
+func (ks *KLESimulation) Apply() error {
+	var allErrs field.ErrorList
+	if ks.Candidates < 1 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("candidates"), ks.Candidates, "must be at least 1"))
+	}
+	if ks.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("duration"), ks.Duration.String(), "must be greater than zero"))
+	}
+	if ks.Interval <= 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("interval"), ks.Interval.String(), "must be greater than zero"))
+	}
+	switch ks.Output {
+	case OutputTable, OutputJSON:
+	default:
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("output"), ks.Output, []string{OutputTable, OutputJSON}))
+	}
+	allErrs = append(allErrs, validateFaults(ks.Faults, field.NewPath("faults"))...)
+	// The candidates always campaign.
+	ks.LeaderElection.LeaderElect = true
+	allErrs = append(allErrs, leaderelection.ValidateLeaderElectionConfiguration(&ks.LeaderElection, field.NewPath("leaderElection"))...)
+	if !leaderelection.UsesKubernetes(ks.LeaderElection.ResourceLock) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("leaderElection", "resourceLock"), ks.LeaderElection.ResourceLock, "must live on the API server to be simulated"))
+	}
+	return allErrs.ToAggregate()
+}
+
+// Run runs the simulation and prints its report to out.
+func (ks *KLESimulation) Run(ctx context.Context, out io.Writer) error {
+	kubeClient, err := fakeclient.KubernetesWithFaults(ks.Faults)
+	if err != nil {
+		return fmt.Errorf("create kubernetes client, err: %w", err)
+	}
+	seed := ks.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	klog.Infof("Simulating %d candidates for %v with seed %d", ks.Candidates, ks.Duration, seed)
+
+	s := &simulation{
+		config:  &ks.LeaderElection,
+		backend: fmt.Sprintf("%s lock on a fake clientset checking resource versions", ks.LeaderElection.ResourceLock),
+		faults:  ks.Faults.String(),
+		client:  kubeClient,
+		rand:    rand.New(rand.NewPCG(seed, seed)),
+		start:   time.Now(),
+	}
+	for i := range ks.Candidates {
+		s.candidates = append(s.candidates, &simCandidate{identity: fmt.Sprintf("candidate-%d", i)})
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ks.Duration)
+	defer cancel()
+	for _, c := range s.candidates {
+		s.startCandidate(ctx, c)
+	}
+	s.disrupt(ctx, ks.Interval)
+	report := s.stop()
+	report.Seed = seed
+
+	if ks.Output == OutputJSON {
+		return json.NewEncoder(out).Encode(report)
+	}
+	return report.write(out)
+}
+
+// simulation runs candidates and records when each of them leads.
+type simulation struct {
+	config     *componentbaseconfig.LeaderElectionConfiguration
+	backend    string
+	faults     string
+	client     clientset.Interface
+	rand       *rand.Rand
+	candidates []*simCandidate
+
+	start                   time.Time
+	kills, pauses, restarts int
+
+	mu     sync.Mutex
+	events []leadership
+}
+
+// leadership is a candidate starting or stopping to lead.
+type leadership struct {
+	at        time.Time
+	candidate string
+	leading   bool
+}
+
+func (s *simulation) record(candidate string, leading bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, leadership{at: time.Now(), candidate: candidate, leading: leading})
+}
+
+// startCandidate runs the leader election loop of c until it is killed or
+// ctx is done.
+func (s *simulation) startCandidate(ctx context.Context, c *simCandidate) {
+	c.mu.Lock()
+	c.killed = false
+	c.mu.Unlock()
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+	opts := leaderelection.DefaultOptions()
+	opts.Identity = c.identity
+	go func() {
+		defer close(c.done)
+		err := leaderelection.NewLeaderElection(func(ctx context.Context) {
+			s.record(c.identity, true)
+			<-ctx.Done()
+			s.record(c.identity, false)
+		}, &simClient{Interface: s.client, candidate: c}, s.config, opts, ctx)
+		if err != nil {
+			klog.Errorf("Candidate %s stopped, err: %v", c.identity, err)
+		}
+	}()
+}
+
+// disrupt kills, pauses and restarts candidates at random until ctx is
+// done.
+func (s *simulation) disrupt(ctx context.Context, interval time.Duration) {
+	for {
+		// Uniform over [interval/2, 3*interval/2), so interval on average.
+		wait := interval/2 + time.Duration(s.rand.Int64N(int64(interval)))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		var alive, dead []*simCandidate
+		for _, c := range s.candidates {
+			if c.isKilled() {
+				dead = append(dead, c)
+			} else {
+				alive = append(alive, c)
+			}
+		}
+		switch {
+		case len(dead) > 0 && (len(alive) == 0 || s.rand.IntN(3) == 0):
+			c := dead[s.rand.IntN(len(dead))]
+			// Wait for its previous life to be over.
+			<-c.done
+			klog.Infof("Restarting %s", c.identity)
+			s.restarts++
+			s.startCandidate(ctx, c)
+		case s.rand.IntN(2) == 0:
+			c := s.target(alive)
+			klog.Infof("Killing %s", c.identity)
+			s.kills++
+			c.kill()
+		default:
+			c := s.target(alive)
+			// Up to twice the lease duration, long enough for some pauses to
+			// outlive the lease.
+			d := time.Duration(s.rand.Int64N(int64(2*s.config.LeaseDuration.Duration))) + time.Second
+			klog.Infof("Pausing %s for %v", c.identity, d)
+			s.pauses++
+			c.pause(d)
+		}
+	}
+}
+
+// target picks the candidate to disrupt among alive, the leader half of
+// the time so that failovers happen.
+func (s *simulation) target(alive []*simCandidate) *simCandidate {
+	if s.rand.IntN(2) == 0 {
+		s.mu.Lock()
+		leading := s.leading(time.Now())
+		s.mu.Unlock()
+		for _, c := range alive {
+			if leading[c.identity] {
+				return c
+			}
+		}
+	}
+	return alive[s.rand.IntN(len(alive))]
+}
+
+// leading returns the candidates leading at t. s.mu must be held.
+func (s *simulation) leading(t time.Time) map[string]bool {
+	leading := map[string]bool{}
+	for _, e := range s.events {
+		if e.at.After(t) {
+			break
+		}
+		if e.leading {
+			leading[e.candidate] = true
+		} else {
+			delete(leading, e.candidate)
+		}
+	}
+	return leading
+}
+
+// stop ends the simulation, waits for every candidate and reports what
+// happened up to now.
+func (s *simulation) stop() *SimulationReport {
+	end := time.Now()
+	for _, c := range s.candidates {
+		c.kill()
+	}
+	for _, c := range s.candidates {
+		<-c.done
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	report := &SimulationReport{
+		Candidates: len(s.candidates),
+		Backend:    s.backend,
+		Faults:     s.faults,
+		Duration:   metav1.Duration{Duration: end.Sub(s.start).Truncate(time.Millisecond)},
+		Kills:      s.kills,
+		Pauses:     s.pauses,
+		Restarts:   s.restarts,
+	}
+	report.analyze(s.start, end, s.events)
+	return report
+}
+
+// simCandidate is a candidate of a simulation. Its Lease requests fail once
+// it is killed, and block while it is paused.
+type simCandidate struct {
+	identity string
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu     sync.Mutex
+	killed bool
+	// resumed is closed when a pause ends, nil while not paused.
+	resumed chan struct{}
+}
+
+// kill stops the candidate the way a crash would: it does not get to
+// release its leases.
+func (c *simCandidate) kill() {
+	c.mu.Lock()
+	c.killed = true
+	if c.resumed != nil {
+		close(c.resumed)
+		c.resumed = nil
+	}
+	c.mu.Unlock()
+	c.cancel()
+}
+
+func (c *simCandidate) isKilled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.killed
+}
+
+// pause blocks the Lease requests of the candidate for d, the way a
+// stopped process would not make any. Its leadership context lives on.
+func (c *simCandidate) pause(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resumed != nil {
+		return
+	}
+	resumed := make(chan struct{})
+	c.resumed = resumed
+	time.AfterFunc(d, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.resumed == resumed {
+			close(resumed)
+			c.resumed = nil
+		}
+	})
+}
+
+// admit holds a Lease request while the candidate is paused and fails it
+// once the candidate is killed.
+func (c *simCandidate) admit() error {
+	c.mu.Lock()
+	resumed := c.resumed
+	c.mu.Unlock()
+	if resumed != nil {
+		<-resumed
+	}
+	if c.isKilled() {
+		return errKilled
+	}
+	return nil
+}
+
+// simClient is the view a candidate has of the shared clientset.
+type simClient struct {
+	clientset.Interface
+	candidate *simCandidate
+}
+
+func (c *simClient) CoordinationV1() coordinationv1client.CoordinationV1Interface {
+	return &simCoordination{CoordinationV1Interface: c.Interface.CoordinationV1(), candidate: c.candidate}
+}
+
+type simCoordination struct {
+	coordinationv1client.CoordinationV1Interface
+	candidate *simCandidate
+}
+
+func (c *simCoordination) Leases(namespace string) coordinationv1client.LeaseInterface {
+	return &simLeases{LeaseInterface: c.CoordinationV1Interface.Leases(namespace), candidate: c.candidate}
+}
+
+type simLeases struct {
+	coordinationv1client.LeaseInterface
+	candidate *simCandidate
+}
+
+func (l *simLeases) Create(ctx context.Context, lease *coordinationv1.Lease, opts metav1.CreateOptions) (*coordinationv1.Lease, error) {
+	if err := l.candidate.admit(); err != nil {
+		return nil, err
+	}
+	return l.LeaseInterface.Create(ctx, lease, opts)
+}
+
+func (l *simLeases) Update(ctx context.Context, lease *coordinationv1.Lease, opts metav1.UpdateOptions) (*coordinationv1.Lease, error) {
+	if err := l.candidate.admit(); err != nil {
+		return nil, err
+	}
+	return l.LeaseInterface.Update(ctx, lease, opts)
+}
+
+func (l *simLeases) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	if err := l.candidate.admit(); err != nil {
+		return err
+	}
+	return l.LeaseInterface.Delete(ctx, name, opts)
+}
+
+func (l *simLeases) Get(ctx context.Context, name string, opts metav1.GetOptions) (*coordinationv1.Lease, error) {
+	if err := l.candidate.admit(); err != nil {
+		return nil, err
+	}
+	return l.LeaseInterface.Get(ctx, name, opts)
+}
+
+func (l *simLeases) List(ctx context.Context, opts metav1.ListOptions) (*coordinationv1.LeaseList, error) {
+	if err := l.candidate.admit(); err != nil {
+		return nil, err
+	}
+	return l.LeaseInterface.List(ctx, opts)
+}
+
+func (l *simLeases) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	if err := l.candidate.admit(); err != nil {
+		return nil, err
+	}
+	return l.LeaseInterface.Watch(ctx, opts)
+}
+
+func (l *simLeases) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*coordinationv1.Lease, error) {
+	if err := l.candidate.admit(); err != nil {
+		return nil, err
+	}
+	return l.LeaseInterface.Patch(ctx, name, pt, data, opts, subresources...)
+}
+
+// SimulationReport sums up how an election behaved during a simulation.
+type SimulationReport struct {
+	Candidates int `json:"candidates"`
+	// Backend is what the candidates elected a leader on, and Faults the
+	// faults injected into its requests.
+	Backend  string          `json:"backend"`
+	Faults   string          `json:"faults"`
+	Duration metav1.Duration `json:"duration"`
+	Seed     uint64          `json:"seed"`
+	Kills    int             `json:"kills"`
+	Pauses   int             `json:"pauses"`
+	Restarts int             `json:"restarts"`
+	// Terms is the number of times a candidate started to lead, and
+	// Transitions the number of times it was another one than the last.
+	Terms       int `json:"terms"`
+	Transitions int `json:"transitions"`
+	// Leaderless is the time without a leader, the initial election
+	// included.
+	Leaderless metav1.Duration `json:"leaderless"`
+	// Overlaps is the number of times a candidate started to lead while
+	// another one still did, and Overlap the time with several leaders.
+	Overlaps int             `json:"overlaps"`
+	Overlap  metav1.Duration `json:"overlap"`
+	// FailoverLatency are percentiles of the time from the last leader
+	// stopping to lead to the next one starting.
+	Failovers       int                        `json:"failovers"`
+	FailoverLatency map[string]metav1.Duration `json:"failoverLatency,omitempty"`
+}
+
+// failoverPercentiles are the percentiles of the failover latency reported.
+var failoverPercentiles = []struct {
+	name string
+	q    float64
+}{
+	{"p50", 0.5},
+	{"p90", 0.9},
+	{"p99", 0.99},
+	{"max", 1},
+}
+
+// analyze sweeps the leadership events between start and end.
+func (r *SimulationReport) analyze(start, end time.Time, events []leadership) {
+	slices.SortStableFunc(events, func(a, b leadership) int { return a.at.Compare(b.at) })
+
+	var (
+		leading             = map[string]bool{}
+		last                = start
+		previous            string
+		leaderless, overlap time.Duration
+		lostAt              time.Time
+		failovers           []time.Duration
+	)
+	advance := func(t time.Time) {
+		switch {
+		case len(leading) == 0:
+			leaderless += t.Sub(last)
+		case len(leading) > 1:
+			overlap += t.Sub(last)
+		}
+		last = t
+	}
+	for _, e := range events {
+		if e.at.After(end) {
+			break
+		}
+		advance(e.at)
+		if !e.leading {
+			delete(leading, e.candidate)
+			if len(leading) == 0 {
+				lostAt = e.at
+			}
+			continue
+		}
+
+		if len(leading) > 0 {
+			r.Overlaps++
+		}
+		leading[e.candidate] = true
+		r.Terms++
+		if previous != "" && previous != e.candidate {
+			r.Transitions++
+		}
+		previous = e.candidate
+		if !lostAt.IsZero() {
+			failovers = append(failovers, e.at.Sub(lostAt))
+			lostAt = time.Time{}
+		}
+	}
+	advance(end)
+
+	r.Leaderless = metav1.Duration{Duration: leaderless.Truncate(time.Millisecond)}
+	r.Overlap = metav1.Duration{Duration: overlap.Truncate(time.Millisecond)}
+	r.Failovers = len(failovers)
+	if len(failovers) == 0 {
+		return
+	}
+	slices.Sort(failovers)
+	r.FailoverLatency = map[string]metav1.Duration{}
+	for _, p := range failoverPercentiles {
+		i := max(int(float64(len(failovers))*p.q+0.5)-1, 0)
+		r.FailoverLatency[p.name] = metav1.Duration{Duration: failovers[min(i, len(failovers)-1)].Truncate(time.Millisecond)}
+	}
+}
+
+func (r *SimulationReport) write(out io.Writer) error {
+	tw := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Candidates:\t%d\n", r.Candidates)
+	_, _ = fmt.Fprintf(tw, "Backend:\t%s\n", r.Backend)
+	_, _ = fmt.Fprintf(tw, "Faults:\t%s\n", r.Faults)
+	_, _ = fmt.Fprintf(tw, "Duration:\t%v\n", r.Duration.Duration)
+	_, _ = fmt.Fprintf(tw, "Seed:\t%d\n", r.Seed)
+	_, _ = fmt.Fprintf(tw, "Disruptions:\t%d kills, %d pauses, %d restarts\n", r.Kills, r.Pauses, r.Restarts)
+	_, _ = fmt.Fprintf(tw, "Terms:\t%d\n", r.Terms)
+	_, _ = fmt.Fprintf(tw, "Transitions:\t%d\n", r.Transitions)
+	_, _ = fmt.Fprintf(tw, "Leaderless:\t%v (%.1f%%)\n", r.Leaderless.Duration, 100*r.Leaderless.Seconds()/r.Duration.Seconds())
+	_, _ = fmt.Fprintf(tw, "Overlap:\t%v in %d overlaps\n", r.Overlap.Duration, r.Overlaps)
+	_, _ = fmt.Fprintf(tw, "Failovers:\t%d\n", r.Failovers)
+	for _, p := range failoverPercentiles {
+		if latency, ok := r.FailoverLatency[p.name]; ok {
+			_, _ = fmt.Fprintf(tw, "Failover latency %s:\t%v\n", p.name, latency.Duration)
+		}
+	}
+	return tw.Flush()
+}
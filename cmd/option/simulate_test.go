@@ -0,0 +1,131 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package option
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSimulationReportAnalyze(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds float64) time.Time {
+		return start.Add(time.Duration(seconds * float64(time.Second)))
+	}
+	leads := func(seconds float64, candidate string) leadership {
+		return leadership{at: at(seconds), candidate: candidate, leading: true}
+	}
+	stops := func(seconds float64, candidate string) leadership {
+		return leadership{at: at(seconds), candidate: candidate}
+	}
+	d := func(seconds float64) metav1.Duration {
+		return metav1.Duration{Duration: time.Duration(seconds * float64(time.Second))}
+	}
+	latencies := func(p50, p90, p99, maximum float64) map[string]metav1.Duration {
+		return map[string]metav1.Duration{"p50": d(p50), "p90": d(p90), "p99": d(p99), "max": d(maximum)}
+	}
+
+	tests := []struct {
+		name   string
+		end    float64
+		events []leadership
+		want   SimulationReport
+	}{
+		{
+			name: "no leader",
+			end:  10,
+			want: SimulationReport{Leaderless: d(10)},
+		},
+		{
+			name:   "single leader",
+			end:    10,
+			events: []leadership{leads(1, "a")},
+			want:   SimulationReport{Terms: 1, Leaderless: d(1)},
+		},
+		{
+			name:   "failover",
+			end:    10,
+			events: []leadership{leads(1, "a"), stops(4, "a"), leads(6, "b")},
+			want: SimulationReport{
+				Terms: 2, Transitions: 1, Leaderless: d(3),
+				Failovers: 1, FailoverLatency: latencies(2, 2, 2, 2),
+			},
+		},
+		{
+			name:   "leader taking the lease back",
+			end:    10,
+			events: []leadership{leads(1, "a"), stops(3, "a"), leads(4, "a")},
+			want: SimulationReport{
+				Terms: 2, Leaderless: d(2),
+				Failovers: 1, FailoverLatency: latencies(1, 1, 1, 1),
+			},
+		},
+		{
+			name:   "overlap",
+			end:    10,
+			events: []leadership{leads(1, "a"), leads(4, "b"), stops(5, "a")},
+			want: SimulationReport{
+				Terms: 2, Transitions: 1, Leaderless: d(1),
+				Overlaps: 1, Overlap: d(1),
+			},
+		},
+		{
+			name: "unsorted events and events after the end",
+			end:  10,
+			events: []leadership{
+				leads(6, "b"), stops(12, "b"), stops(4, "a"), leads(1, "a"), leads(13, "a"),
+			},
+			want: SimulationReport{
+				Terms: 2, Transitions: 1, Leaderless: d(3),
+				Failovers: 1, FailoverLatency: latencies(2, 2, 2, 2),
+			},
+		},
+		{
+			name: "failover latency percentiles",
+			end:  15,
+			events: []leadership{
+				leads(0, "a"), stops(1, "a"),
+				leads(2, "b"), stops(3, "b"),
+				leads(5, "a"), stops(6, "a"),
+				leads(9, "b"), stops(10, "b"),
+				leads(14, "a"),
+			},
+			want: SimulationReport{
+				Terms: 5, Transitions: 4, Leaderless: d(10),
+				Failovers: 4, FailoverLatency: latencies(2, 4, 4, 4),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got SimulationReport
+			got.analyze(start, at(tt.end), tt.events)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("analyze() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package option
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/yshngg/kle/pkg/client"
+	"github.com/yshngg/kle/pkg/leaderelection"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	componentbaseconfig "k8s.io/component-base/config"
+	componentbaseoptions "k8s.io/component-base/config/options"
+	"sigs.k8s.io/yaml"
+)
+
+// The output formats of KLEStatus.
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// KLEStatus prints the status of the leases of an election.
+type KLEStatus struct {
+	Output   string
+	Watch    bool
+	Interval time.Duration
+	Shards   int
+
+	LeaderElection   componentbaseconfig.LeaderElectionConfiguration
+	ClientConnection componentbaseconfig.ClientConnectionConfiguration
+}
+
+func NewKLEStatus() *KLEStatus {
+	return &KLEStatus{
+		Output:         OutputTable,
+		Interval:       time.Second,
+		LeaderElection: *leaderelection.DefaultLeaderElectionConfig(),
+	}
+}
+
+// AddFlags adds flags for a specific KLEStatus to the specified FlagSet
+func (ks *KLEStatus) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&ks.Output, "output", "o", ks.Output, "Output format, one of '"+OutputTable+"', '"+OutputJSON+"' or '"+OutputYAML+"'.")
+	fs.BoolVarP(&ks.Watch, "watch", "w", ks.Watch, "Keep watching the leases and print them again whenever the holder, the transitions or the state change.")
+	fs.DurationVar(&ks.Interval, "interval", ks.Interval, "How often the leases are read in watch mode.")
+	fs.IntVar(&ks.Shards, "leader-elect-shards", ks.Shards, "The number of leases of a sharded election, called <resource-name>-<index>. 0 reads the lease named by --leader-elect-resource-name.")
+
+	fs.StringVar(&ks.ClientConnection.Kubeconfig, "kubeconfig", ks.ClientConnection.Kubeconfig, "File with kube configuration. Deprecated, use client-connection-kubeconfig instead.")
+	fs.StringVar(&ks.ClientConnection.Kubeconfig, "client-connection-kubeconfig", ks.ClientConnection.Kubeconfig, "File path to kube configuration for interacting with kubernetes apiserver.")
+	fs.Float32Var(&ks.ClientConnection.QPS, "client-connection-qps", ks.ClientConnection.QPS, "QPS to use for interacting with kubernetes apiserver.")
+	fs.Int32Var(&ks.ClientConnection.Burst, "client-connection-burst", ks.ClientConnection.Burst, "Burst to use for interacting with kubernetes apiserver.")
+
+	componentbaseoptions.BindLeaderElectionFlags(&ks.LeaderElection, fs)
+}
+
+func (ks *KLEStatus) Apply() error {
+	switch ks.Output {
+	case OutputTable, OutputJSON, OutputYAML:
+	default:
+		return fmt.Errorf("unknown output format %q, must be one of %q, %q or %q", ks.Output, OutputTable, OutputJSON, OutputYAML)
+	}
+	if ks.Watch && ks.Interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %v", ks.Interval)
+	}
+	return nil
+}
+
+func (ks *KLEStatus) Run(ctx context.Context, out io.Writer) error {
+	var kubeClient clientset.Interface
+	if leaderelection.UsesKubernetes(ks.LeaderElection.ResourceLock) {
+		var err error
+		kubeClient, err = client.Kubernetes(ks.ClientConnection)
+		if err != nil {
+			return fmt.Errorf("create kubernetes client, err: %w", err)
+		}
+	}
+
+	names := []string{ks.LeaderElection.ResourceName}
+	if ks.Shards > 0 {
+		names = names[:0]
+		for i := range ks.Shards {
+			names = append(names, leaderelection.ShardName(ks.LeaderElection.ResourceName, i))
+		}
+	}
+	locks := make([]resourcelock.Interface, 0, len(names))
+	for _, name := range names {
+		// Only reads, the identity is never written.
+		lock, err := leaderelection.NewBackend(ks.LeaderElection.ResourceLock, ks.LeaderElection.ResourceNamespace, name, kubeClient, resourcelock.ResourceLockConfig{})
+		if err != nil {
+			return fmt.Errorf("create leader election lock, err: %w", err)
+		}
+		locks = append(locks, lock)
+	}
+
+	read := func() ([]leaderelection.LeaseStatus, error) {
+		now := time.Now()
+		statuses := make([]leaderelection.LeaseStatus, 0, len(locks))
+		for _, lock := range locks {
+			status, err := leaderelection.ReadLeaseStatus(ctx, lock, now)
+			if err != nil {
+				return nil, fmt.Errorf("read lease %s, err: %w", lock.Describe(), err)
+			}
+			statuses = append(statuses, status)
+		}
+		return statuses, nil
+	}
+
+	statuses, err := read()
+	if err != nil {
+		return err
+	}
+	w := &statusWriter{out: out, format: ks.Output}
+	if err := w.write(statuses); err != nil {
+		return err
+	}
+	if !ks.Watch {
+		return nil
+	}
+
+	last := statuses
+	err = wait.PollUntilContextCancel(ctx, ks.Interval, false, func(context.Context) (bool, error) {
+		statuses, err := read()
+		if err != nil {
+			return false, err
+		}
+		var changed []leaderelection.LeaseStatus
+		for i, status := range statuses {
+			if leaseChanged(last[i], status) {
+				changed = append(changed, status)
+			}
+		}
+		last = statuses
+		if len(changed) == 0 {
+			return false, nil
+		}
+		return false, w.write(changed)
+	})
+	if ctx.Err() != nil {
+		// Interrupted, not a failure.
+		return nil
+	}
+	return err
+}
+
+// leaseChanged reports whether a lease changed in a way worth printing in
+// watch mode. Renewals alone are not.
+func leaseChanged(old, cur leaderelection.LeaseStatus) bool {
+	return old.Holder != cur.Holder || old.Transitions != cur.Transitions || old.State != cur.State
+}
+
+// statusWriter prints lease statuses in one of the output formats. The
+// table header is printed once.
+type statusWriter struct {
+	out    io.Writer
+	format string
+	header bool
+}
+
+func (sw *statusWriter) write(statuses []leaderelection.LeaseStatus) error {
+	switch sw.format {
+	case OutputJSON:
+		enc := json.NewEncoder(sw.out)
+		for _, status := range statuses {
+			if err := enc.Encode(status); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OutputYAML:
+		for _, status := range statuses {
+			b, err := yaml.Marshal(status)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(sw.out, "---\n%s", b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(sw.out, 0, 8, 2, ' ', 0)
+	if !sw.header {
+		sw.header = true
+		_, _ = fmt.Fprintln(tw, "LEASE\tHOLDER\tSTATE\tACQUIRED\tRENEWED\tSINCE RENEW\tDURATION\tTRANSITIONS\tEXPIRED")
+	}
+	for _, s := range statuses {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%v\t%v\t%d\t%t\n",
+			s.Lease, orNone(s.Holder), s.State, formatTime(s.AcquireTime.Time), formatTime(s.RenewTime.Time),
+			s.SinceRenew.Duration, s.LeaseDuration.Duration, s.Transitions, s.Expired)
+	}
+	return tw.Flush()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "<none>"
+	}
+	return t.Format(time.RFC3339)
+}
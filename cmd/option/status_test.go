@@ -0,0 +1,88 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package option
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yshngg/kle/pkg/leaderelection"
+)
+
+func TestStatusWriter(t *testing.T) {
+	statuses := []leaderelection.LeaseStatus{
+		{Lease: "demo/kle-0", State: leaderelection.LeaseStateHeld, Holder: "a", Transitions: 1},
+		{Lease: "demo/kle-1", State: leaderelection.LeaseStateMissing},
+	}
+	tests := []struct {
+		format string
+		// want is the output of two writes of statuses.
+		want []string
+	}{
+		{format: OutputJSON, want: []string{`{"lease":"demo/kle-0","state":"held","holder":"a",`, `{"lease":"demo/kle-1","state":"missing",`}},
+		{format: OutputYAML, want: []string{"---\n", "holder: a\n", "lease: demo/kle-1\n"}},
+		{format: OutputTable, want: []string{"LEASE", "demo/kle-0  a", "demo/kle-1  <none>  missing  <none>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			w := &statusWriter{out: &out, format: tt.format}
+			for range 2 {
+				if err := w.write(statuses); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output %q does not contain %q", out.String(), want)
+				}
+			}
+			if n := strings.Count(out.String(), "LEASE"); tt.format == OutputTable && n != 1 {
+				t.Errorf("table header printed %d times, want once", n)
+			}
+		})
+	}
+}
+
+func TestLeaseChanged(t *testing.T) {
+	held := leaderelection.LeaseStatus{Lease: "demo/kle", State: leaderelection.LeaseStateHeld, Holder: "a", Transitions: 1}
+	tests := []struct {
+		name   string
+		modify func(*leaderelection.LeaseStatus)
+		want   bool
+	}{
+		{name: "renewed", modify: func(s *leaderelection.LeaseStatus) { s.SinceRenew.Duration = 0 }},
+		{name: "new holder", modify: func(s *leaderelection.LeaseStatus) { s.Holder, s.Transitions = "b", 2 }, want: true},
+		{name: "expired", modify: func(s *leaderelection.LeaseStatus) { s.State, s.Expired = leaderelection.LeaseStateExpired, true }, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cur := held
+			tt.modify(&cur)
+			if got := leaseChanged(held, cur); got != tt.want {
+				t.Errorf("leaseChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -23,15 +23,24 @@
 package cmd
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"io"
 	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/yshngg/kle/cmd/option"
+	"github.com/yshngg/kle/pkg/leaderelection"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
 )
 
+// ExitCodeLeadershipLost is the exit status used when leadership is lost and
+// the loss policy is exit-error, so that such restarts can be told apart from
+// crashes.
+const ExitCodeLeadershipLost = 3
+
 func NewKLECommand(out io.Writer) *cobra.Command {
 	s := option.NewKLEServer()
 	cmd := &cobra.Command{
@@ -40,10 +49,12 @@ func NewKLECommand(out io.Writer) *cobra.Command {
 		Long:  ``,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			if err = s.Apply(); err != nil {
-				klog.Errorf("apply kle, err: %v", err)
+				reportInvalid(cmd, err)
+				klog.V(1).Infof("apply kle, err: %v", err)
 				return err
 			}
 
+			cmd.SilenceUsage = true
 			if err = s.Run(cmd.Context()); err != nil {
 				klog.Errorf("run kle, err: %v", err)
 				return err
@@ -55,16 +66,37 @@ func NewKLECommand(out io.Writer) *cobra.Command {
 	return cmd
 }
 
+// reportInvalid prints every problem of an invalid configuration at once,
+// before anything starts, in place of cobra's report of err.
+func reportInvalid(cmd *cobra.Command, err error) {
+	var agg utilerrors.Aggregate
+	if !errors.As(err, &agg) {
+		return
+	}
+	_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "Error: invalid configuration:")
+	for _, e := range agg.Errors() {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "  %v\n", e)
+	}
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+}
+
 func Execute() {
 	out := os.Stdout
 	cmd := NewKLECommand(out)
 	cmd.AddCommand(NewVersionCommand())
+	cmd.AddCommand(NewStepDownCommand(out))
+	cmd.AddCommand(NewStatusCommand(out))
+	cmd.AddCommand(NewSimulateCommand(out))
 
 	klogFlags := flag.NewFlagSet("klog", flag.ExitOnError)
 	klog.InitFlags(klogFlags)
 	cmd.Flags().AddGoFlagSet(klogFlags)
 
 	err := cmd.Execute()
+	if errors.Is(err, leaderelection.ErrLeadershipLost) {
+		os.Exit(ExitCodeLeadershipLost)
+	}
 	if err != nil {
 		os.Exit(1)
 	}
@@ -0,0 +1,62 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/yshngg/kle/cmd/option"
+	"k8s.io/klog/v2"
+)
+
+func NewSimulateCommand(out io.Writer) *cobra.Command {
+	s := option.NewKLESimulation()
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Simulate an election between several candidates",
+		Long: `Runs --candidates leader election loops in one process against a shared
+fake clientset for --duration, while killing, pausing and restarting them at
+random. A killed candidate does not release its lease, a paused one makes no
+requests but keeps leading as far as it knows. Prints the number of
+transitions, the time without a leader, any overlap between two leaders and
+percentiles of the failover latency.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if err = s.Apply(); err != nil {
+				reportInvalid(cmd, err)
+				klog.V(1).Infof("apply simulation, err: %v", err)
+				return err
+			}
+
+			cmd.SilenceUsage = true
+			ctx, done := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+			defer done()
+			return s.Run(ctx, out)
+		},
+	}
+	s.AddFlags(cmd.Flags())
+	return cmd
+}
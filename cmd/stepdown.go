@@ -0,0 +1,114 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yshngg/kle/cmd/option"
+)
+
+func NewStepDownCommand(out io.Writer) *cobra.Command {
+	var (
+		addr      = "localhost:2190"
+		token     string
+		tokenFile string
+		coolDown  time.Duration
+		timeout   = 10 * time.Second
+	)
+	cmd := &cobra.Command{
+		Use:   "step-down",
+		Short: "Ask a kle replica to step down",
+		Long: `Asks the kle replica serving on --addr to release its leadership and to
+stay out of the race for its cool-down, without stopping it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			token, err := option.ReadToken(token, tokenFile)
+			if err != nil {
+				return err
+			}
+			if token == "" {
+				return fmt.Errorf("a token is required, set --token or --token-file")
+			}
+
+			u, err := releaseURL(addr, coolDown)
+			if err != nil {
+				return err
+			}
+			req, err := http.NewRequestWithContext(cmd.Context(), http.MethodPost, u, nil)
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			resp, err := (&http.Client{Timeout: timeout}).Do(req)
+			if err != nil {
+				return fmt.Errorf("step down, err: %w", err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return fmt.Errorf("read response, err: %w", err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("step down, %s: %s", resp.Status, strings.TrimSpace(string(body)))
+			}
+			_, err = out.Write(body)
+			return err
+		},
+	}
+	fs := cmd.Flags()
+	fs.StringVar(&addr, "addr", addr, "The address of the kle replica, with or without an http:// or https:// scheme.")
+	fs.StringVar(&token, "token", token, "The bearer token the replica was started with.")
+	fs.StringVar(&tokenFile, "token-file", tokenFile, "File holding the bearer token, used if --token is empty.")
+	fs.DurationVar(&coolDown, "cool-down", coolDown, "How long the replica stays out of the race. 0 uses the replica's --leader-elect-release-cool-down.")
+	fs.DurationVar(&timeout, "timeout", timeout, "How long to wait for the replica to respond.")
+	return cmd
+}
+
+// releaseURL builds the URL of the release route of the replica on addr.
+func releaseURL(addr string, coolDown time.Duration) (string, error) {
+	if !strings.Contains(addr, "://") {
+		if strings.HasPrefix(addr, ":") {
+			addr = "localhost" + addr
+		}
+		addr = "http://" + addr
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", fmt.Errorf("parse address %q, err: %w", addr, err)
+	}
+	u.Path = option.ReleasePath
+	if coolDown > 0 {
+		u.RawQuery = url.Values{option.CoolDownParam: []string{coolDown.String()}}.Encode()
+	}
+	return u.String(), nil
+}
@@ -25,6 +25,9 @@ package client
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -32,11 +35,84 @@ import (
 	componentbaseconfig "k8s.io/component-base/config"
 )
 
-func Kubernetes(clientConnection componentbaseconfig.ClientConnectionConfiguration) (clientset.Interface, error) {
+// DateObserver is told the Date header of an API server response, and the
+// local time halfway through the request.
+type DateObserver func(server, local time.Time)
+
+// Kubernetes returns a clientset for clientConnection. Every observer is told
+// the Date of the API server responses.
+func Kubernetes(clientConnection componentbaseconfig.ClientConnectionConfiguration, observers ...DateObserver) (clientset.Interface, error) {
 	cfg, err := createConfig(clientConnection)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create config: %v", err)
 	}
+	for _, observe := range observers {
+		cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &dateRoundTripper{next: rt, observe: observe}
+		})
+	}
+
+	return clientset.NewForConfig(cfg)
+}
+
+// Cluster is a kubeconfig and one of its contexts.
+type Cluster struct {
+	Kubeconfig string
+	// Context is the context to use, the current context of the kubeconfig
+	// when empty.
+	Context string
+}
+
+// ParseCluster parses a cluster formatted as <kubeconfig>[@<context>].
+func ParseCluster(s string) (Cluster, error) {
+	kubeconfig, context, _ := strings.Cut(s, "@")
+	if kubeconfig == "" {
+		return Cluster{}, fmt.Errorf("parse cluster %q, err: %w", s, errors.New("kubeconfig may not be empty"))
+	}
+	return Cluster{Kubeconfig: kubeconfig, Context: context}, nil
+}
+
+// String formats the cluster the way ParseCluster parses it.
+func (c Cluster) String() string {
+	if c.Context == "" {
+		return c.Kubeconfig
+	}
+	return c.Kubeconfig + "@" + c.Context
+}
+
+// Name returns the name of the context of the cluster.
+func (c Cluster) Name() (string, error) {
+	if c.Context != "" {
+		return c.Context, nil
+	}
+	config, err := clientcmd.LoadFromFile(c.Kubeconfig)
+	if err != nil {
+		return "", fmt.Errorf("load kubeconfig %s, err: %w", c.Kubeconfig, err)
+	}
+	if config.CurrentContext == "" {
+		return "", fmt.Errorf("kubeconfig %s has no current context", c.Kubeconfig)
+	}
+	return config.CurrentContext, nil
+}
+
+// KubernetesForCluster returns a clientset for cluster, with the QPS and
+// burst of clientConnection. Every observer is told the Date of the API
+// server responses.
+func KubernetesForCluster(clientConnection componentbaseconfig.ClientConnectionConfiguration, cluster Cluster, observers ...DateObserver) (clientset.Interface, error) {
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: cluster.Kubeconfig},
+		&clientcmd.ConfigOverrides{CurrentContext: cluster.Context},
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to build config for cluster %s: %v", cluster, err)
+	}
+	cfg.Burst = int(clientConnection.Burst)
+	cfg.QPS = clientConnection.QPS
+	for _, observe := range observers {
+		cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &dateRoundTripper{next: rt, observe: observe}
+		})
+	}
 
 	return clientset.NewForConfig(cfg)
 }
@@ -84,3 +160,25 @@ func getMasterFromKubeconfig(filename string) (string, error) {
 	}
 	return val.Server, nil
 }
+
+// dateRoundTripper tells observe the Date of every response.
+type dateRoundTripper struct {
+	next    http.RoundTripper
+	observe DateObserver
+}
+
+func (rt *dateRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	// The headers of a watch arrive long before it ends.
+	if req.URL.Query().Get("watch") == "true" {
+		return resp, nil
+	}
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		rt.observe(date, start.Add(time.Since(start)/2))
+	}
+	return resp, nil
+}
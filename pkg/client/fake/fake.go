@@ -23,10 +23,190 @@
 package fake
 
 import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	clientset "k8s.io/client-go/kubernetes"
 	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
-func Kubernetes() (clientset.Interface, error) {
-	return fakeclientset.NewClientset(), nil
+// Kubernetes returns a fake clientset seeded with objects, which checks
+// resource versions.
+func Kubernetes(objects ...runtime.Object) (clientset.Interface, error) {
+	client := fakeclientset.NewClientset(objects...)
+	CheckResourceVersions(client)
+	return client, nil
+}
+
+// KubernetesWithFaults returns a fake clientset seeded with objects, which
+// checks resource versions and injects faults into its Lease requests.
+func KubernetesWithFaults(faults Faults, objects ...runtime.Object) (clientset.Interface, error) {
+	client := fakeclientset.NewClientset(objects...)
+	CheckResourceVersions(client)
+	faults.Inject(client)
+	return client, nil
+}
+
+// CheckResourceVersions adds a reactor ahead of the other reactors of
+// client, which versions objects the way the API server does: every write
+// gets a new resourceVersion, and an update carrying a stale one fails with
+// a conflict. The tracker of the fake clientset alone lets a leader that
+// lost the lease overwrite it.
+func CheckResourceVersions(client *fakeclientset.Clientset) {
+	tracker := client.Tracker()
+	reaction := k8stesting.ObjectReaction(tracker)
+	// The fake clientset serves one request at a time, so the version is
+	// not raced for.
+	var version uint64
+	bump := func(obj metav1.Object) {
+		version++
+		obj.SetResourceVersion(strconv.FormatUint(version, 10))
+	}
+	client.PrependReactor("*", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gvr, ns := action.GetResource(), action.GetNamespace()
+		switch action.GetVerb() {
+		case "create", "update":
+			obj := action.(interface{ GetObject() runtime.Object }).GetObject().DeepCopyObject()
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				return false, nil, nil
+			}
+			if action.GetVerb() == "update" && accessor.GetResourceVersion() != "" {
+				current, err := tracker.Get(gvr, ns, accessor.GetName())
+				if err != nil {
+					return true, nil, err
+				}
+				if currentAccessor, err := meta.Accessor(current); err == nil && currentAccessor.GetResourceVersion() != accessor.GetResourceVersion() {
+					return true, nil, apierrors.NewConflict(gvr.GroupResource(), accessor.GetName(),
+						fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+				}
+			}
+			bump(accessor)
+			if action.GetVerb() == "create" {
+				return true, obj, tracker.Create(gvr, obj, ns)
+			}
+			return true, obj, tracker.Update(gvr, obj, ns)
+		case "patch":
+			handled, obj, err := reaction(action)
+			if err != nil || obj == nil {
+				return handled, obj, err
+			}
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				return handled, obj, nil
+			}
+			bump(accessor)
+			return true, obj, tracker.Update(gvr, obj, ns)
+		default:
+			return false, nil, nil
+		}
+	})
+}
+
+// ErrPartitioned is returned by every Lease request once a fake clientset
+// is partitioned.
+var ErrPartitioned = errors.New("injected network partition")
+
+// Faults are failures injected into the Lease requests of a fake clientset,
+// so that renew failures, conflicts and leadership loss can be reproduced
+// without a cluster. Its zero value injects none.
+type Faults struct {
+	// Latency delays every Lease request. The fake clientset serves one
+	// request at a time, so it delays concurrent requests further.
+	Latency time.Duration
+	// ErrorRate is the probability, from 0 to 1, for a Lease request to fail
+	// with an internal error.
+	ErrorRate float64
+	// ConflictRate is the probability, from 0 to 1, for a Lease create,
+	// update or patch to fail with a conflict.
+	ConflictRate float64
+	// PartitionAfter, when positive, makes every Lease request fail with
+	// ErrPartitioned once that long has passed since the faults were
+	// injected.
+	PartitionAfter time.Duration
+}
+
+// Enabled reports whether any fault is injected.
+func (f Faults) Enabled() bool {
+	return f != Faults{}
+}
+
+// String describes the faults injected, e.g. "latency 50ms, error rate 0.1".
+func (f Faults) String() string {
+	var faults []string
+	if f.Latency > 0 {
+		faults = append(faults, fmt.Sprintf("latency %v", f.Latency))
+	}
+	if f.ErrorRate > 0 {
+		faults = append(faults, fmt.Sprintf("error rate %g", f.ErrorRate))
+	}
+	if f.ConflictRate > 0 {
+		faults = append(faults, fmt.Sprintf("conflict rate %g", f.ConflictRate))
+	}
+	if f.PartitionAfter > 0 {
+		faults = append(faults, fmt.Sprintf("partition after %v", f.PartitionAfter))
+	}
+	if len(faults) == 0 {
+		return "none"
+	}
+	return strings.Join(faults, ", ")
+}
+
+// Inject adds reactors injecting the faults into the Lease requests of
+// client, ahead of its other reactors.
+func (f Faults) Inject(client *fakeclientset.Clientset) {
+	if !f.Enabled() {
+		return
+	}
+	var partitionAt time.Time
+	if f.PartitionAfter > 0 {
+		partitionAt = time.Now().Add(f.PartitionAfter)
+	}
+	client.PrependReactor("*", "leases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if f.Latency > 0 {
+			time.Sleep(f.Latency)
+		}
+		if !partitionAt.IsZero() && !time.Now().Before(partitionAt) {
+			return true, nil, ErrPartitioned
+		}
+		if f.ErrorRate > 0 && rand.Float64() < f.ErrorRate {
+			return true, nil, apierrors.NewInternalError(errors.New("injected error"))
+		}
+		if f.ConflictRate > 0 && isWrite(action) && rand.Float64() < f.ConflictRate {
+			return true, nil, apierrors.NewConflict(coordinationv1.Resource("leases"), name(action), errors.New("injected conflict"))
+		}
+		return false, nil, nil
+	})
+}
+
+func isWrite(action k8stesting.Action) bool {
+	switch action.GetVerb() {
+	case "create", "update", "patch":
+		return true
+	default:
+		return false
+	}
+}
+
+// name returns the name of the object action is about, if known.
+func name(action k8stesting.Action) string {
+	switch a := action.(type) {
+	case k8stesting.PatchAction:
+		return a.GetName()
+	case interface{ GetObject() runtime.Object }:
+		if obj, ok := a.GetObject().(metav1.Object); ok {
+			return obj.GetName()
+		}
+	}
+	return ""
 }
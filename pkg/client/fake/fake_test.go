@@ -0,0 +1,138 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKubernetesChecksResourceVersions(t *testing.T) {
+	ctx := context.Background()
+	client, err := Kubernetes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	leases := client.CoordinationV1().Leases("default")
+
+	created, err := leases.Create(ctx, &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: "kle"}}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Create() err = %v", err)
+	}
+	if created.ResourceVersion == "" {
+		t.Fatalf("Create() did not set a resource version")
+	}
+
+	holder := "a"
+	stale := created.DeepCopy()
+	created.Spec.HolderIdentity = &holder
+	updated, err := leases.Update(ctx, created, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Update() err = %v", err)
+	}
+	if updated.ResourceVersion == created.ResourceVersion {
+		t.Errorf("Update() kept resource version %s", updated.ResourceVersion)
+	}
+
+	holder = "b"
+	stale.Spec.HolderIdentity = &holder
+	if _, err := leases.Update(ctx, stale, metav1.UpdateOptions{}); !apierrors.IsConflict(err) {
+		t.Fatalf("Update() of a stale lease err = %v, want a conflict", err)
+	}
+	got, err := leases.Get(ctx, "kle", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	if *got.Spec.HolderIdentity != "a" {
+		t.Errorf("holder = %s, want a", *got.Spec.HolderIdentity)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	tests := []struct {
+		name       string
+		faults     Faults
+		wantString string
+		// wantGet and wantCreate check the errors of a Lease get and create.
+		wantGet, wantCreate func(error) bool
+	}{
+		{name: "none", wantString: "none", wantGet: apierrors.IsNotFound, wantCreate: noError},
+		{
+			name:       "errors",
+			faults:     Faults{ErrorRate: 1},
+			wantString: "error rate 1",
+			wantGet:    apierrors.IsInternalError,
+			wantCreate: apierrors.IsInternalError,
+		},
+		{
+			name:       "conflicts",
+			faults:     Faults{ConflictRate: 1},
+			wantString: "conflict rate 1",
+			wantGet:    apierrors.IsNotFound,
+			wantCreate: apierrors.IsConflict,
+		},
+		{
+			name:       "partition",
+			faults:     Faults{Latency: time.Millisecond, PartitionAfter: time.Nanosecond},
+			wantString: "latency 1ms, partition after 1ns",
+			wantGet:    isPartitioned,
+			wantCreate: isPartitioned,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.faults.String(); got != tt.wantString {
+				t.Errorf("String() = %q, want %q", got, tt.wantString)
+			}
+			if got, want := tt.faults.Enabled(), tt.faults != (Faults{}); got != want {
+				t.Errorf("Enabled() = %v, want %v", got, want)
+			}
+			client, err := KubernetesWithFaults(tt.faults)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			leases := client.CoordinationV1().Leases("default")
+			if _, err := leases.Get(ctx, "kle", metav1.GetOptions{}); !tt.wantGet(err) {
+				t.Errorf("Get() err = %v", err)
+			}
+			if _, err := leases.Create(ctx, &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: "kle"}}, metav1.CreateOptions{}); !tt.wantCreate(err) {
+				t.Errorf("Create() err = %v", err)
+			}
+			// Only Lease requests are failed.
+			if _, err := client.CoreV1().Pods("default").List(ctx, metav1.ListOptions{}); err != nil {
+				t.Errorf("List() of pods err = %v", err)
+			}
+		})
+	}
+}
+
+func noError(err error) bool { return err == nil }
+
+func isPartitioned(err error) bool { return errors.Is(err, ErrPartitioned) }
@@ -0,0 +1,120 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// Backend is a lock leader election campaigns on. Every Backend satisfies
+// resourcelock.Interface, so it can be handed to client-go as is.
+type Backend interface {
+	resourcelock.Interface
+}
+
+// BackendFunc creates the Backend for the lock called name in namespace.
+// client is nil when no Kubernetes client is available, backends that do not
+// talk to the API server must not rely on it.
+type BackendFunc func(namespace, name string, client clientset.Interface, rlc resourcelock.ResourceLockConfig) (Backend, error)
+
+type backend struct {
+	new BackendFunc
+	// rules are the RBAC rules needed on the API server, nil for backends
+	// which do not talk to it.
+	rules []rbacv1.PolicyRule
+	// members creates the Membership used alongside the backend, nil when
+	// there is none.
+	members MembershipFunc
+}
+
+// backends holds the Backends by resource lock type.
+var backends = map[string]backend{
+	LeasesResourceLock:           {new: kubernetesBackend(LeasesResourceLock), members: newLeaseMembership, rules: []rbacv1.PolicyRule{leaseRule}},
+	ConfigMapsResourceLock:       {new: kubernetesBackend(ConfigMapsResourceLock), members: newLeaseMembership, rules: []rbacv1.PolicyRule{configMapRule}},
+	EndpointsResourceLock:        {new: kubernetesBackend(EndpointsResourceLock), members: newLeaseMembership, rules: []rbacv1.PolicyRule{endpointsRule}},
+	ConfigMapsLeasesResourceLock: {new: kubernetesBackend(ConfigMapsLeasesResourceLock), members: newLeaseMembership, rules: []rbacv1.PolicyRule{configMapRule, leaseRule}},
+	EndpointsLeasesResourceLock:  {new: kubernetesBackend(EndpointsLeasesResourceLock), members: newLeaseMembership, rules: []rbacv1.PolicyRule{endpointsRule, leaseRule}},
+	FileResourceLock:             {new: newFileBackend},
+	MemoryResourceLock:           {new: newMemoryBackend, members: newMemoryMembership},
+}
+
+// RegisterBackend makes a Backend available as resource lock lockType.
+// members may be nil if the backend has no Membership. rules are the RBAC
+// rules it needs on the API server, if any. It is not safe to call
+// concurrently with leader election and is meant to be called from init
+// functions.
+func RegisterBackend(lockType string, fn BackendFunc, members MembershipFunc, rules ...rbacv1.PolicyRule) {
+	backends[lockType] = backend{new: fn, members: members, rules: rules}
+}
+
+// NewBackend creates the Backend of the given resource lock type.
+func NewBackend(lockType, namespace, name string, client clientset.Interface, rlc resourcelock.ResourceLockConfig) (Backend, error) {
+	b, ok := backends[lockType]
+	if !ok {
+		return nil, invalidLockTypeError(lockType)
+	}
+	if len(name) == 0 {
+		return nil, fmt.Errorf("name may not be empty")
+	}
+	return b.new(namespace, name, client, rlc)
+}
+
+// ResourceLockTypes returns the supported resource lock types, sorted.
+func ResourceLockTypes() []string {
+	types := make([]string, 0, len(backends))
+	for lockType := range backends {
+		types = append(types, lockType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ResourceLockRBAC returns the RBAC rules needed to campaign on lockType.
+func ResourceLockRBAC(lockType string) ([]rbacv1.PolicyRule, error) {
+	b, ok := backends[lockType]
+	if !ok {
+		return nil, invalidLockTypeError(lockType)
+	}
+	return b.rules, nil
+}
+
+// UsesKubernetes reports whether lockType needs a Kubernetes client.
+func UsesKubernetes(lockType string) bool {
+	return len(backends[lockType].rules) > 0
+}
+
+// SupportsMembership reports whether candidates on lockType know about each
+// other, which sharding, priorities and forwarding to the leader rely on.
+func SupportsMembership(lockType string) bool {
+	return backends[lockType].members != nil
+}
+
+func invalidLockTypeError(lockType string) error {
+	return fmt.Errorf("invalid resource lock %q, must be one of %s", lockType, strings.Join(ResourceLockTypes(), ", "))
+}
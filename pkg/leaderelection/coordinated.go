@@ -0,0 +1,99 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/klog/v2"
+)
+
+// leaseCandidateGroupVersion serves the LeaseCandidate API used by
+// coordinated leader election.
+const leaseCandidateGroupVersion = "coordination.k8s.io/v1beta1"
+
+var leaseCandidateRule = rbacv1.PolicyRule{
+	APIGroups: []string{"coordination.k8s.io"},
+	Resources: []string{"leasecandidates"},
+	Verbs:     []string{"get", "list", "watch", "create", "update", "delete"},
+}
+
+// coordinatedUnsupported returns why coordinated leader election cannot be
+// used for lockType, or an empty string if it can.
+func coordinatedUnsupported(lockType string, client clientset.Interface) string {
+	if lockType != LeasesResourceLock {
+		return fmt.Sprintf("resource lock %q is not %q", lockType, LeasesResourceLock)
+	}
+	resources, err := client.Discovery().ServerResourcesForGroupVersion(leaseCandidateGroupVersion)
+	if err != nil {
+		return fmt.Sprintf("discover %s, err: %v", leaseCandidateGroupVersion, err)
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == "leasecandidates" {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s does not serve leasecandidates", leaseCandidateGroupVersion)
+}
+
+// validateLeaseCandidateName reports why identity cannot name a
+// LeaseCandidate. The API server writes the name of the candidate it picks
+// into the Lease, and the candidate only leads once the holder is its own
+// identity, so the candidate is named exactly after it.
+func validateLeaseCandidateName(identity string) error {
+	if errs := validation.IsDNS1123Subdomain(identity); len(errs) > 0 {
+		return fmt.Errorf("identity %q cannot name a lease candidate: %s", identity, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// startLeaseCandidate advertises the candidate for the lease election in
+// namespace until ctx is done, so that the API server can pick it as the
+// holder. It returns once the candidate is being kept up to date.
+func startLeaseCandidate(ctx context.Context, client clientset.Interface, namespace, election, identity, binaryVersion string) error {
+	lc, waiter, err := leaderelection.NewCandidate(
+		client,
+		namespace,
+		identity,
+		election,
+		binaryVersion,
+		// kle does not emulate older versions of itself.
+		binaryVersion,
+		coordinationv1.OldestEmulationVersion,
+	)
+	if err != nil {
+		return fmt.Errorf("create lease candidate, err: %v", err)
+	}
+
+	go lc.Run(ctx)
+	waiter.WaitForCacheSync(ctx.Done())
+	klog.Infof("Running as lease candidate %s/%s with version %s", namespace, identity, binaryVersion)
+	return nil
+}
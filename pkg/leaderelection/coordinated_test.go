@@ -0,0 +1,145 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+func TestValidateLeaseCandidateName(t *testing.T) {
+	tests := []struct {
+		identity string
+		wantErr  bool
+	}{
+		{identity: "kle-0"},
+		{identity: "kle-0.demo"},
+		{identity: "demo/kle-0", wantErr: true},
+		{identity: "host_1234", wantErr: true},
+		{identity: "Kle", wantErr: true},
+		{identity: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.identity, func(t *testing.T) {
+			if err := validateLeaseCandidateName(tt.identity); (err != nil) != tt.wantErr {
+				t.Errorf("validateLeaseCandidateName(%q) = %v, want error %v", tt.identity, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestCoordinated plays the API server picking a lease candidate, and checks
+// that the candidate leads once the holder is the name of its LeaseCandidate.
+func TestCoordinated(t *testing.T) {
+	tests := []struct {
+		name string
+		// holder returns the holder written into the Lease, given the name
+		// of the LeaseCandidate.
+		holder    func(candidate string) string
+		wantLeads bool
+	}{
+		{name: "candidate picked", holder: func(candidate string) string { return candidate }, wantLeads: true},
+		{name: "another candidate picked", holder: func(string) string { return "kle-1" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fakeclientset.NewClientset(&coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "kle"},
+			})
+			client.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{{
+				GroupVersion: leaseCandidateGroupVersion,
+				APIResources: []metav1.APIResource{{Name: "leasecandidates", Namespaced: true, Kind: "LeaseCandidate"}},
+			}}
+			config := &componentbaseconfig.LeaderElectionConfiguration{
+				LeaderElect:       true,
+				LeaseDuration:     metav1.Duration{Duration: 2 * time.Second},
+				RenewDeadline:     metav1.Duration{Duration: 1500 * time.Millisecond},
+				RetryPeriod:       metav1.Duration{Duration: 100 * time.Millisecond},
+				ResourceLock:      LeasesResourceLock,
+				ResourceName:      "kle",
+				ResourceNamespace: "demo",
+			}
+			opts := DefaultOptions()
+			opts.Identity = "kle-0"
+			opts.Coordinated = true
+			opts.BinaryVersion = "1.0.0"
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			leads := make(chan struct{}, 1)
+			go func() {
+				defer close(done)
+				run := func(ctx context.Context) {
+					leads <- struct{}{}
+					<-ctx.Done()
+				}
+				if err := NewLeaderElection(run, client, config, opts, ctx); err != nil {
+					t.Error(err)
+				}
+			}()
+			defer func() {
+				cancel()
+				<-done
+			}()
+
+			var candidate string
+			waitFor(t, 5*time.Second, func() bool {
+				candidates, err := client.CoordinationV1beta1().LeaseCandidates("demo").List(ctx, metav1.ListOptions{})
+				if err != nil || len(candidates.Items) == 0 {
+					return false
+				}
+				candidate = candidates.Items[0].Name
+				return true
+			})
+			lease, err := client.CoordinationV1().Leases("demo").Get(ctx, "kle", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			now := metav1.NewMicroTime(time.Now())
+			holder, duration := tt.holder(candidate), int32(15)
+			lease.Spec.HolderIdentity, lease.Spec.LeaseDurationSeconds = &holder, &duration
+			lease.Spec.AcquireTime, lease.Spec.RenewTime = &now, &now
+			if _, err := client.CoordinationV1().Leases("demo").Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case <-leads:
+				if !tt.wantLeads {
+					t.Errorf("candidate %s leads while the holder is %s", candidate, tt.holder(candidate))
+				}
+			case <-time.After(2 * time.Second):
+				if tt.wantLeads {
+					t.Errorf("candidate %s does not lead while it is the holder", candidate)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+)
+
+// The reasons of the Events recorded on leadership changes.
+const (
+	EventReasonLeadershipAcquired = "LeadershipAcquired"
+	EventReasonLeadershipLost     = "LeadershipLost"
+	EventReasonLeadershipReleased = "LeadershipReleased"
+	EventReasonLeaseRenewFailed   = "LeaseRenewFailed"
+	EventReasonNewLeaderObserved  = "NewLeaderObserved"
+)
+
+var eventRule = rbacv1.PolicyRule{
+	APIGroups: []string{""},
+	Resources: []string{"events"},
+	Verbs:     []string{"create", "patch", "update"},
+}
+
+// eventTimeout bounds looking up the Lease an Event is recorded on.
+const eventTimeout = 5 * time.Second
+
+// events records leadership changes as Events on the Lease, for the lock
+// types that have one, and on the Pod of the candidate, if the Downward API
+// names it. A nil *events records nothing.
+type events struct {
+	recorder  record.EventRecorder
+	client    clientset.Interface
+	namespace string
+	leases    bool
+	pod       *corev1.ObjectReference
+}
+
+func newEvents(recorder record.EventRecorder, client clientset.Interface, lockType, namespace string) *events {
+	if recorder == nil {
+		return nil
+	}
+	e := &events{
+		recorder:  recorder,
+		client:    client,
+		namespace: namespace,
+	}
+	switch lockType {
+	case LeasesResourceLock, ConfigMapsLeasesResourceLock, EndpointsLeasesResourceLock:
+		e.leases = client != nil
+	}
+	if name := os.Getenv(PodNameEnv); name != "" {
+		e.pod = &corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Pod",
+			Namespace:  os.Getenv(PodNamespaceEnv),
+			Name:       name,
+			UID:        types.UID(os.Getenv(PodUIDEnv)),
+		}
+	}
+	return e
+}
+
+func (e *events) acquired(lease, identity string) {
+	e.record(lease, corev1.EventTypeNormal, EventReasonLeadershipAcquired, "%s acquired lease %s", identity, lease)
+}
+
+func (e *events) lost(lease, identity string) {
+	e.record(lease, corev1.EventTypeWarning, EventReasonLeadershipLost, "%s lost lease %s", identity, lease)
+}
+
+func (e *events) released(lease, identity string) {
+	e.record(lease, corev1.EventTypeNormal, EventReasonLeadershipReleased, "%s released lease %s", identity, lease)
+}
+
+func (e *events) renewFailed(lease, identity string, err error) {
+	e.record(lease, corev1.EventTypeWarning, EventReasonLeaseRenewFailed, "%s failed to renew lease %s: %v", identity, lease, err)
+}
+
+func (e *events) newLeader(lease, identity, leader string) {
+	e.record(lease, corev1.EventTypeNormal, EventReasonNewLeaderObserved, "%s observed %s as the new leader of lease %s", identity, leader, lease)
+}
+
+// record looks up the Lease in the background, leader election callbacks
+// and renewals must not wait on it.
+func (e *events) record(lease, eventType, reason, messageFmt string, args ...interface{}) {
+	if e == nil {
+		return
+	}
+	go func() {
+		if e.pod != nil {
+			e.recorder.Eventf(e.pod, eventType, reason, messageFmt, args...)
+		}
+		if obj := e.lease(lease); obj != nil {
+			e.recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+		}
+	}()
+}
+
+// lease returns the Lease named name, nil if there is none.
+func (e *events) lease(name string) runtime.Object {
+	if !e.leases {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), eventTimeout)
+	defer cancel()
+	lease, err := e.client.CoordinationV1().Leases(e.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		klog.V(4).Infof("Not recording event on lease %s/%s, err: %v", e.namespace, name, err)
+		return nil
+	}
+	// Populate the type meta, so we don't have to get it from the schema
+	lease.Kind = "Lease"
+	lease.APIVersion = coordinationv1.SchemeGroupVersion.String()
+	return lease
+}
+
+// watch wraps lock to record renewals that fail while leading.
+func (e *events) watch(lease string, lock resourcelock.Interface, leading *atomic.Bool) resourcelock.Interface {
+	if e == nil {
+		return lock
+	}
+	return &eventLock{Interface: lock, lease: lease, events: e, leading: leading}
+}
+
+type eventLock struct {
+	resourcelock.Interface
+	lease   string
+	events  *events
+	leading *atomic.Bool
+}
+
+func (l *eventLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	err := l.Interface.Update(ctx, ler)
+	if err != nil && l.leading.Load() && ler.HolderIdentity == l.Identity() {
+		l.events.renewFailed(l.lease, l.Identity(), err)
+	}
+	return err
+}
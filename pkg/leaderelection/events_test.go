@@ -0,0 +1,94 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+// TestEvents leads a lease once and checks that every leadership change is
+// recorded once, by kle only.
+func TestEvents(t *testing.T) {
+	client := fakeclientset.NewClientset()
+	recorder := record.NewFakeRecorder(100)
+	config := &componentbaseconfig.LeaderElectionConfiguration{
+		LeaderElect:       true,
+		LeaseDuration:     metav1.Duration{Duration: 2 * time.Second},
+		RenewDeadline:     metav1.Duration{Duration: 1500 * time.Millisecond},
+		RetryPeriod:       metav1.Duration{Duration: 100 * time.Millisecond},
+		ResourceLock:      LeasesResourceLock,
+		ResourceName:      "kle",
+		ResourceNamespace: "demo",
+	}
+	opts := DefaultOptions()
+	opts.Identity = "kle-0"
+	opts.EventRecorder = recorder
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	run := func(ctx context.Context) {
+		// Leads for a few renewals, then releases the lease.
+		time.Sleep(500 * time.Millisecond)
+		cancel()
+	}
+	if err := NewLeaderElection(run, client, config, opts, ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{}
+	// Events are recorded in the background.
+	deadline := time.After(5 * time.Second)
+	for counts[EventReasonLeadershipReleased] == 0 {
+		select {
+		case event := <-recorder.Events:
+			// A FakeRecorder formats events as "<type> <reason> <message>".
+			counts[strings.Fields(event)[1]]++
+		case <-deadline:
+			t.Fatalf("no %s event, got %v", EventReasonLeadershipReleased, counts)
+		}
+	}
+	// Wait for stray events.
+	for {
+		select {
+		case event := <-recorder.Events:
+			counts[strings.Fields(event)[1]]++
+			continue
+		case <-time.After(200 * time.Millisecond):
+		}
+		break
+	}
+
+	want := map[string]int{EventReasonLeadershipAcquired: 1, EventReasonLeadershipReleased: 1}
+	if !maps.Equal(counts, want) {
+		t.Errorf("events = %v, want %v", counts, want)
+	}
+}
@@ -0,0 +1,222 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+)
+
+type fencingTokenKey struct{}
+
+// FencingToken orders leadership terms. Every term of a lease gets a token
+// greater than the tokens of the terms before it, so that external systems
+// can turn away a deposed leader that is still writing.
+//
+// Transitions is the leaseTransitions of the lease when the term started,
+// which only grows as leadership moves between candidates. Epoch tells
+// apart the terms of a candidate that takes the lease back without a
+// transition, it is the start of the term in seconds since the Unix epoch,
+// made strictly increasing within the process.
+//
+// Lease is the lease the term leads. The tokens of different leases are
+// not ordered, so they travel and are checked along with their lease.
+type FencingToken struct {
+	Lease       string `json:"lease,omitempty"`
+	Transitions uint32 `json:"transitions"`
+	Epoch       uint32 `json:"epoch"`
+}
+
+// Uint64 returns the token as a single number, comparable within its
+// lease.
+func (t FencingToken) Uint64() uint64 {
+	return uint64(t.Transitions)<<32 | uint64(t.Epoch)
+}
+
+// String returns the token the way it travels over the wire, as the decimal
+// Uint64.
+func (t FencingToken) String() string {
+	return strconv.FormatUint(t.Uint64(), 10)
+}
+
+// Less reports whether t belongs to an earlier term than o, of the same
+// lease.
+func (t FencingToken) Less(o FencingToken) bool {
+	return t.Uint64() < o.Uint64()
+}
+
+// ParseFencingToken parses a token formatted by FencingToken.String, which
+// leaves the lease out.
+func ParseFencingToken(s string) (FencingToken, error) {
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return FencingToken{}, fmt.Errorf("parse fencing token %q, err: %w", s, err)
+	}
+	return FencingToken{Transitions: uint32(v >> 32), Epoch: uint32(v)}, nil
+}
+
+// FencingTokenFromContext returns the fencing token of the term a
+// leadership context was handed out for.
+func FencingTokenFromContext(ctx context.Context) (FencingToken, bool) {
+	token, ok := ctx.Value(fencingTokenKey{}).(FencingToken)
+	return token, ok
+}
+
+// WithFencingToken returns a copy of ctx carrying token.
+func WithFencingToken(ctx context.Context, token FencingToken) context.Context {
+	return context.WithValue(ctx, fencingTokenKey{}, token)
+}
+
+var (
+	epochMu   sync.Mutex
+	lastEpoch uint32
+)
+
+// nextEpoch returns the epoch of a term starting now.
+func nextEpoch() uint32 {
+	epochMu.Lock()
+	defer epochMu.Unlock()
+	lastEpoch = max(lastEpoch+1, uint32(time.Now().Unix()))
+	return lastEpoch
+}
+
+// FencingStatus tracks the fencing tokens of the terms this candidate is
+// leading. Its zero value is ready to use, and it serves itself as JSON.
+type FencingStatus struct {
+	mu     sync.Mutex
+	tokens map[string]FencingToken
+}
+
+// fencingStatusEntry is what FencingStatus serves per lease.
+type fencingStatusEntry struct {
+	FencingToken
+	Token string `json:"token"`
+}
+
+// ServeHTTP writes the fencing token of every lease led as JSON.
+func (s *FencingStatus) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	resp := make(map[string]fencingStatusEntry, len(s.tokens))
+	for lease, token := range s.tokens {
+		resp[lease] = fencingStatusEntry{FencingToken: token, Token: token.String()}
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		klog.Errorf("failed to write response: %v", err)
+	}
+}
+
+// Token returns the fencing token of the term leading lease, if any.
+func (s *FencingStatus) Token(lease string) (FencingToken, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[lease]
+	return token, ok
+}
+
+func (s *FencingStatus) set(lease string, token FencingToken, leading bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !leading {
+		delete(s.tokens, lease)
+		return
+	}
+	if s.tokens == nil {
+		s.tokens = map[string]FencingToken{}
+	}
+	s.tokens[lease] = token
+}
+
+// fencingLock starts a term, and picks its fencing token, on every write
+// under our identity that changes the acquire time of the record. The
+// token of the term a write belongs to travels with the write's context,
+// so that the locks it wraps can publish it.
+type fencingLock struct {
+	resourcelock.Interface
+	// lease names the lease in the tokens.
+	lease string
+
+	mu          sync.Mutex
+	acquireTime int64
+	term        FencingToken
+}
+
+func (l *fencingLock) Create(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	ctx, term, ok := l.next(ctx, ler)
+	err := l.Interface.Create(ctx, ler)
+	l.observe(ler, term, ok && err == nil)
+	return err
+}
+
+func (l *fencingLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	ctx, term, ok := l.next(ctx, ler)
+	err := l.Interface.Update(ctx, ler)
+	l.observe(ler, term, ok && err == nil)
+	return err
+}
+
+// next returns the token of the term ler belongs to, if it is written under
+// our identity. Acquire times are compared to the second, the precision
+// some locks store them with.
+func (l *fencingLock) next(ctx context.Context, ler resourcelock.LeaderElectionRecord) (context.Context, FencingToken, bool) {
+	if ler.HolderIdentity != l.Identity() {
+		return ctx, FencingToken{}, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	term := l.term
+	if ler.AcquireTime.Unix() != l.acquireTime {
+		term = FencingToken{Lease: l.lease, Transitions: uint32(ler.LeaderTransitions), Epoch: nextEpoch()}
+	}
+	return WithFencingToken(ctx, term), term, true
+}
+
+func (l *fencingLock) observe(ler resourcelock.LeaderElectionRecord, term FencingToken, written bool) {
+	if !written {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.acquireTime = ler.AcquireTime.Unix()
+	l.term = term
+}
+
+// token returns the fencing token of the term last written.
+func (l *fencingLock) token() FencingToken {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.term
+}
@@ -0,0 +1,193 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func TestFencingTokenLess(t *testing.T) {
+	tests := []struct {
+		name string
+		t, o FencingToken
+		want bool
+	}{
+		{name: "equal", t: FencingToken{Transitions: 1, Epoch: 5}, o: FencingToken{Transitions: 1, Epoch: 5}},
+		{name: "earlier epoch", t: FencingToken{Transitions: 1, Epoch: 5}, o: FencingToken{Transitions: 1, Epoch: 6}, want: true},
+		{name: "later epoch", t: FencingToken{Transitions: 1, Epoch: 6}, o: FencingToken{Transitions: 1, Epoch: 5}},
+		{name: "transitions win over epoch", t: FencingToken{Transitions: 1, Epoch: 900}, o: FencingToken{Transitions: 2, Epoch: 5}, want: true},
+		{name: "later transitions", t: FencingToken{Transitions: 2, Epoch: 5}, o: FencingToken{Transitions: 1, Epoch: 900}},
+		{name: "zero token", t: FencingToken{}, o: FencingToken{Epoch: 1}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.Less(tt.o); got != tt.want {
+				t.Errorf("%+v.Less(%+v) = %v, want %v", tt.t, tt.o, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFencingToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    FencingToken
+		wantErr bool
+	}{
+		{name: "zero", s: "0", want: FencingToken{}},
+		{name: "epoch only", s: "1700000000", want: FencingToken{Epoch: 1700000000}},
+		{name: "transitions and epoch", s: "4294967298", want: FencingToken{Transitions: 1, Epoch: 2}},
+		{name: "largest", s: "18446744073709551615", want: FencingToken{Transitions: 1<<32 - 1, Epoch: 1<<32 - 1}},
+		{name: "empty", s: "", wantErr: true},
+		{name: "negative", s: "-1", wantErr: true},
+		{name: "not a number", s: "abc", wantErr: true},
+		{name: "overflow", s: "18446744073709551616", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFencingToken(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFencingToken(%q) error = %v, want error %v", tt.s, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseFencingToken(%q) = %+v, want %+v", tt.s, got, tt.want)
+			}
+			if got.String() != tt.s {
+				t.Errorf("%+v.String() = %q, want %q", got, got.String(), tt.s)
+			}
+		})
+	}
+}
+
+func TestFencingLock(t *testing.T) {
+	start := metav1.NewTime(time.Unix(1700000000, 0))
+	later := metav1.NewTime(start.Add(time.Minute))
+	record := func(holder string, acquired metav1.Time, transitions int) resourcelock.LeaderElectionRecord {
+		return resourcelock.LeaderElectionRecord{HolderIdentity: holder, AcquireTime: acquired, RenewTime: acquired, LeaderTransitions: transitions}
+	}
+	// Each write is compared with the token after the write before it.
+	type write struct {
+		record resourcelock.LeaderElectionRecord
+		want   string
+	}
+	tests := []struct {
+		name   string
+		writes []write
+	}{
+		{
+			name: "renewals keep the term",
+			writes: []write{
+				{record: record("a", start, 0), want: "newer"},
+				{record: record("a", start, 0), want: "same"},
+				{record: record("a", metav1.NewTime(start.Add(500*time.Millisecond)), 0), want: "same"},
+			},
+		},
+		{
+			name: "new acquire time starts a term",
+			writes: []write{
+				{record: record("a", start, 0), want: "newer"},
+				{record: record("a", later, 0), want: "newer"},
+			},
+		},
+		{
+			name: "transition starts a later term",
+			writes: []write{
+				{record: record("a", later, 0), want: "newer"},
+				{record: record("a", start, 1), want: "newer"},
+			},
+		},
+		{
+			name: "writes of other holders are ignored",
+			writes: []write{
+				{record: record("a", start, 0), want: "newer"},
+				{record: record("", later, 0), want: "same"},
+				{record: record("b", later, 1), want: "same"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The token a write is made with is read from its context, the
+			// way the wrapped locks publish it.
+			var written FencingToken
+			var ok bool
+			lock := &fencingLock{Interface: &contextLock{
+				Interface: NewMemoryBackend(NewMemoryStore(), "demo", "kle", resourcelock.ResourceLockConfig{Identity: "a"}),
+				ctx: func(ctx context.Context) {
+					written, ok = FencingTokenFromContext(ctx)
+				},
+			}, lease: "kle"}
+			ctx := context.Background()
+			if err := lock.Create(ctx, resourcelock.LeaderElectionRecord{}); err != nil {
+				t.Fatal(err)
+			}
+			for i, w := range tt.writes {
+				before := lock.token()
+				if err := lock.Update(ctx, w.record); err != nil {
+					t.Fatalf("write %d: %v", i, err)
+				}
+
+				after := lock.token()
+				if after != (FencingToken{}) && after.Lease != "kle" {
+					t.Errorf("write %d: token %s is of lease %q, want kle", i, after, after.Lease)
+				}
+				switch w.want {
+				case "newer":
+					if !before.Less(after) {
+						t.Errorf("write %d: token %s is not newer than %s", i, after, before)
+					}
+				case "same":
+					if after != before {
+						t.Errorf("write %d: token changed from %s to %s", i, before, after)
+					}
+				}
+				if w.record.HolderIdentity == "a" && (!ok || written != after) {
+					t.Errorf("write %d: written with token %s (%v), want %s", i, written, ok, after)
+				}
+				if w.record.HolderIdentity != "a" && ok {
+					t.Errorf("write %d: written with token %s, want none", i, written)
+				}
+			}
+		})
+	}
+}
+
+// contextLock hands the context of every update to ctx.
+type contextLock struct {
+	resourcelock.Interface
+	ctx func(ctx context.Context)
+}
+
+func (l *contextLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	l.ctx(ctx)
+	return l.Interface.Update(ctx, ler)
+}
@@ -0,0 +1,195 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// FileResourceLock campaigns on a local file, the resource name being its
+// path. It lets several processes on one host elect a leader without a
+// cluster.
+const FileResourceLock = "file"
+
+var fileResource = schema.GroupResource{Resource: "file"}
+
+// fileRecord is what a lock file holds.
+type fileRecord struct {
+	// Version is bumped on every write. It plays the part of a Lease
+	// resourceVersion, so updates based on a stale read are rejected.
+	Version uint64                            `json:"version"`
+	Record  resourcelock.LeaderElectionRecord `json:"record"`
+}
+
+// FileLock stores the leader election record in a local file protected by
+// flock.
+type FileLock struct {
+	// Path is the lock file, it is created on first use.
+	Path       string
+	LockConfig resourcelock.ResourceLockConfig
+	// version is the Version observed by the last Get, Create or Update.
+	version uint64
+}
+
+func newFileBackend(_, name string, _ clientset.Interface, rlc resourcelock.ResourceLockConfig) (Backend, error) {
+	return &FileLock{Path: name, LockConfig: rlc}, nil
+}
+
+// Get returns the election record from the lock file.
+func (fl *FileLock) Get(_ context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	f, err := os.Open(fl.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, apierrors.NewNotFound(fileResource, fl.Path)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	if err = lockFile(f, false); err != nil {
+		return nil, nil, fmt.Errorf("lock %s, err: %w", fl.Path, err)
+	}
+	defer unlockFile(f)
+
+	record, err := fl.read(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	if record == nil {
+		return nil, nil, apierrors.NewNotFound(fileResource, fl.Path)
+	}
+	recordBytes, err := json.Marshal(record.Record)
+	if err != nil {
+		return nil, nil, err
+	}
+	fl.version = record.Version
+	return &record.Record, recordBytes, nil
+}
+
+// Create attempts to create the lock file holding the election record.
+func (fl *FileLock) Create(_ context.Context, ler resourcelock.LeaderElectionRecord) error {
+	return fl.write(ler, func(current *fileRecord) error {
+		if current != nil {
+			return apierrors.NewAlreadyExists(fileResource, fl.Path)
+		}
+		return nil
+	})
+}
+
+// Update will update the election record held by the lock file.
+func (fl *FileLock) Update(_ context.Context, ler resourcelock.LeaderElectionRecord) error {
+	return fl.write(ler, func(current *fileRecord) error {
+		if current == nil {
+			return apierrors.NewNotFound(fileResource, fl.Path)
+		}
+		if current.Version != fl.version {
+			return apierrors.NewConflict(fileResource, fl.Path, errors.New("the lock file has been modified since it was read"))
+		}
+		return nil
+	})
+}
+
+// RecordEvent is a no-op, there is no object to record events on.
+func (fl *FileLock) RecordEvent(string) {}
+
+// Describe is used to convert details on current resource lock
+// into a string
+func (fl *FileLock) Describe() string {
+	return fl.Path
+}
+
+// Identity returns the Identity of the lock
+func (fl *FileLock) Identity() string {
+	return fl.LockConfig.Identity
+}
+
+// write replaces the record under an exclusive flock once check accepts the
+// current record, which is nil for an empty or new file.
+func (fl *FileLock) write(ler resourcelock.LeaderElectionRecord, check func(current *fileRecord) error) error {
+	f, err := os.OpenFile(fl.Path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err = lockFile(f, true); err != nil {
+		return fmt.Errorf("lock %s, err: %w", fl.Path, err)
+	}
+	defer unlockFile(f)
+
+	current, err := fl.read(f)
+	if err != nil {
+		return err
+	}
+	if err = check(current); err != nil {
+		return err
+	}
+
+	next := fileRecord{Record: ler}
+	if current != nil {
+		next.Version = current.Version
+	}
+	next.Version++
+	data, err := json.Marshal(next)
+	if err != nil {
+		return err
+	}
+	if err = f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err = f.WriteAt(data, 0); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	fl.version = next.Version
+	return nil
+}
+
+// read decodes the record held by f, nil if f is empty.
+func (fl *FileLock) read(f *os.File) (*fileRecord, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	var record fileRecord
+	if err = json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("decode %s, err: %w", fl.Path, err)
+	}
+	return &record, nil
+}
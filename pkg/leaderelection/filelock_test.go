@@ -0,0 +1,134 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build unix
+
+package leaderelection
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func TestFileLock(t *testing.T) {
+	tests := []struct {
+		name string
+		// content, when set, is written to the lock file first.
+		content string
+		steps   []lockStep
+	}{
+		{
+			name: "get without file",
+			steps: []lockStep{
+				{lock: "a", op: "get", want: notFound},
+			},
+		},
+		{
+			name:    "get empty file",
+			content: "\n",
+			steps: []lockStep{
+				{lock: "a", op: "get", want: notFound},
+				{lock: "a", op: "create", holder: "a", want: succeeds},
+			},
+		},
+		{
+			name: "update without file",
+			steps: []lockStep{
+				{lock: "a", op: "update", holder: "a", want: notFound},
+			},
+		},
+		{
+			name: "create twice",
+			steps: []lockStep{
+				{lock: "a", op: "create", holder: "a", want: succeeds},
+				{lock: "b", op: "create", holder: "b", want: alreadyExists},
+			},
+		},
+		{
+			name: "renew",
+			steps: []lockStep{
+				{lock: "a", op: "create", holder: "a", want: succeeds},
+				{lock: "a", op: "update", holder: "a", want: succeeds},
+				{lock: "a", op: "update", holder: "a", want: succeeds},
+				{lock: "b", op: "get", holder: "a", want: succeeds},
+			},
+		},
+		{
+			name: "update after read",
+			steps: []lockStep{
+				{lock: "a", op: "create", holder: "a", want: succeeds},
+				{lock: "b", op: "get", holder: "a", want: succeeds},
+				{lock: "b", op: "update", holder: "b", want: succeeds},
+				{lock: "a", op: "get", holder: "b", want: succeeds},
+			},
+		},
+		{
+			name: "stale update",
+			steps: []lockStep{
+				{lock: "a", op: "create", holder: "a", want: succeeds},
+				{lock: "b", op: "get", holder: "a", want: succeeds},
+				{lock: "a", op: "update", holder: "a", want: succeeds},
+				{lock: "b", op: "update", holder: "b", want: conflicts},
+				{lock: "b", op: "get", holder: "a", want: succeeds},
+				{lock: "b", op: "update", holder: "b", want: succeeds},
+			},
+		},
+		{
+			name:    "update on version written by another process",
+			content: `{"version":7,"record":{"holderIdentity":"c"}}`,
+			steps: []lockStep{
+				{lock: "a", op: "update", holder: "a", want: conflicts},
+				{lock: "a", op: "get", holder: "c", want: succeeds},
+				{lock: "a", op: "update", holder: "a", want: succeeds},
+			},
+		},
+		{
+			name:    "corrupt file",
+			content: "{",
+			steps: []lockStep{
+				{lock: "a", op: "get", want: func(err error) bool { return err != nil }},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "kle.lock")
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			locks := map[string]resourcelock.Interface{}
+			for _, id := range []string{"a", "b"} {
+				b, err := NewBackend(FileResourceLock, "", path, nil, resourcelock.ResourceLockConfig{Identity: id})
+				if err != nil {
+					t.Fatal(err)
+				}
+				locks[id] = b
+			}
+			runLockSteps(t, locks, tt.steps)
+		})
+	}
+}
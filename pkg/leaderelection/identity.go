@@ -0,0 +1,112 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
+)
+
+// The Downward API environment variables that tell kle which Pod it runs
+// in.
+const (
+	PodNameEnv      = "POD_NAME"
+	PodNamespaceEnv = "POD_NAMESPACE"
+	PodUIDEnv       = "POD_UID"
+	NodeNameEnv     = "NODE_NAME"
+)
+
+// identity returns the identity the candidate holds leases under. In order
+// of preference it is opts.Identity, the content of opts.IdentityFile, the
+// Pod named by the Downward API, or a unique identity made up on the spot.
+// A stable identity lets a restarted candidate take back the lease it still
+// holds instead of waiting for it to expire. If opts.IdentityFile is set but
+// does not exist yet, the identity is persisted there.
+func identity(opts *Options) (string, error) {
+	if opts.Identity != "" {
+		return opts.Identity, nil
+	}
+
+	if opts.IdentityFile != "" {
+		b, err := os.ReadFile(opts.IdentityFile)
+		switch {
+		case err == nil && len(strings.TrimSpace(string(b))) > 0:
+			return strings.TrimSpace(string(b)), nil
+		case err != nil && !errors.Is(err, fs.ErrNotExist):
+			return "", fmt.Errorf("read identity file, err: %w", err)
+		}
+	}
+
+	// In coordinated leader election the identity names a LeaseCandidate in
+	// the namespace of the election, so it must be a valid object name.
+	id := podIdentity(!opts.Coordinated)
+	if id == "" {
+		hostname, err := os.Hostname()
+		separator := "_"
+		if opts.Coordinated {
+			hostname, separator = strings.ToLower(hostname), "-"
+		}
+		if err != nil {
+			// on errors, make sure we're unique
+			id = string(uuid.NewUUID())
+		} else {
+			// add a uniquifier so that two processes on the same host don't accidentally both become active
+			id = hostname + separator + string(uuid.NewUUID())
+		}
+	}
+
+	if opts.IdentityFile != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.IdentityFile), 0o755); err != nil {
+			return "", fmt.Errorf("create identity file directory, err: %w", err)
+		}
+		if err := os.WriteFile(opts.IdentityFile, []byte(id+"\n"), 0o644); err != nil {
+			return "", fmt.Errorf("write identity file, err: %w", err)
+		}
+		klog.V(1).Infof("Persisted identity %s to %s", id, opts.IdentityFile)
+	}
+	return id, nil
+}
+
+// podIdentity names the candidate after its Pod, <namespace>/<name> or only
+// <name> unless namespaced, if the Downward API tells it which Pod it runs
+// in.
+func podIdentity(namespaced bool) string {
+	name := os.Getenv(PodNameEnv)
+	if name == "" {
+		return ""
+	}
+	if node := os.Getenv(NodeNameEnv); node != "" {
+		klog.V(1).Infof("Running in Pod %s on node %s", name, node)
+	}
+	if namespace := os.Getenv(PodNamespaceEnv); namespace != "" && namespaced {
+		return namespace + "/" + name
+	}
+	return name
+}
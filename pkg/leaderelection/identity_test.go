@@ -0,0 +1,90 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIdentity(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skip("no hostname")
+	}
+	tests := []struct {
+		name        string
+		opts        Options
+		env         map[string]string
+		fileContent string
+		// want is the identity expected, or its prefix if wantPrefix.
+		want       string
+		wantPrefix bool
+	}{
+		{name: "explicit", opts: Options{Identity: "kle-0"}, env: map[string]string{PodNameEnv: "pod"}, want: "kle-0"},
+		{name: "identity file", opts: Options{IdentityFile: "id"}, fileContent: " kle-1\n", env: map[string]string{PodNameEnv: "pod"}, want: "kle-1"},
+		{name: "pod", env: map[string]string{PodNameEnv: "pod", PodNamespaceEnv: "demo"}, want: "demo/pod"},
+		{name: "pod without namespace", env: map[string]string{PodNameEnv: "pod"}, want: "pod"},
+		{name: "pod coordinated", opts: Options{Coordinated: true}, env: map[string]string{PodNameEnv: "pod", PodNamespaceEnv: "demo"}, want: "pod"},
+		{name: "made up", want: hostname + "_", wantPrefix: true},
+		{name: "made up coordinated", opts: Options{Coordinated: true}, want: strings.ToLower(hostname) + "-", wantPrefix: true},
+		{name: "persisted", opts: Options{IdentityFile: "id"}, env: map[string]string{PodNameEnv: "pod"}, want: "pod"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, env := range []string{PodNameEnv, PodNamespaceEnv, NodeNameEnv} {
+				t.Setenv(env, tt.env[env])
+			}
+			opts := tt.opts
+			if opts.IdentityFile != "" {
+				opts.IdentityFile = filepath.Join(t.TempDir(), "kle", opts.IdentityFile)
+				if tt.fileContent != "" {
+					if err := os.MkdirAll(filepath.Dir(opts.IdentityFile), 0o755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(opts.IdentityFile, []byte(tt.fileContent), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			got, err := identity(&opts)
+			if err != nil {
+				t.Fatalf("identity() err = %v", err)
+			}
+			if tt.wantPrefix && (!strings.HasPrefix(got, tt.want) || got == tt.want) || !tt.wantPrefix && got != tt.want {
+				t.Errorf("identity() = %q, want %q", got, tt.want)
+			}
+			if opts.IdentityFile == "" {
+				return
+			}
+			// The identity survives a restart.
+			again, err := identity(&Options{IdentityFile: opts.IdentityFile})
+			if err != nil || again != got {
+				t.Errorf("identity() after a restart = %q, %v, want %q", again, err, got)
+			}
+		})
+	}
+}
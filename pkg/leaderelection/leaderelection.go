@@ -25,11 +25,15 @@ package leaderelection
 import (
 	"context"
 	"fmt"
-	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/uuid"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
@@ -37,6 +41,10 @@ import (
 	"k8s.io/klog/v2"
 )
 
+// WorkloadShutdownTimeout bounds how long OnStoppedLeading waits for the
+// workload to return after its leadership context has been cancelled.
+const WorkloadShutdownTimeout = 10 * time.Second
+
 // DefaultLeaderElectionConfig returns the default leader election configuration.
 func DefaultLeaderElectionConfig() *componentbaseconfig.LeaderElectionConfiguration {
 	return &componentbaseconfig.LeaderElectionConfiguration{
@@ -44,75 +52,457 @@ func DefaultLeaderElectionConfig() *componentbaseconfig.LeaderElectionConfigurat
 		LeaseDuration:     metav1.Duration{Duration: 15 * time.Second},
 		RenewDeadline:     metav1.Duration{Duration: 10 * time.Second},
 		RetryPeriod:       metav1.Duration{Duration: 2 * time.Second},
-		ResourceLock:      "leases",
+		ResourceLock:      LeasesResourceLock,
 		ResourceName:      "kle",
 		ResourceNamespace: "demo",
 	}
 }
 
-// NewLeaderElection starts the leader election code loop
+// NewLeaderElection starts the leader election code loop. run is started once
+// leadership is acquired and receives the leadership context, which is
+// cancelled as soon as the lease is lost. What happens after a loss is
+// decided by opts.OnLoss.
 func NewLeaderElection(
-	run func(),
+	run func(ctx context.Context),
 	client clientset.Interface,
 	LeaderElectionConfig *componentbaseconfig.LeaderElectionConfiguration,
+	opts *Options,
 	ctx context.Context,
 ) error {
-	var id string
+	id, err := identity(opts)
+	if err != nil {
+		return err
+	}
+
+	klog.Infof("Campaigning as %s", id)
+
+	rules, err := ResourceLockRBAC(LeaderElectionConfig.ResourceLock)
+	if err != nil {
+		return err
+	}
+	if len(rules) > 0 {
+		klog.Infof("Resource lock %q requires RBAC on %s", LeaderElectionConfig.ResourceLock, describeRules(rules))
+	}
 
-	if hostname, err := os.Hostname(); err != nil {
-		// on errors, make sure we're unique
-		id = string(uuid.NewUUID())
-	} else {
-		// add a uniquifier so that two processes on the same host don't accidentally both become active
-		id = hostname + "_" + string(uuid.NewUUID())
+	rlc := resourcelock.ResourceLockConfig{
+		Identity: id,
 	}
 
-	klog.V(3).Infof("Assigned unique lease holder id: %s", id)
+	// In coordinated mode the API server picks the holder among the lease
+	// candidates, the candidate only follows it.
+	coordinated := opts.Coordinated
+	if coordinated {
+		if reason := coordinatedUnsupported(LeaderElectionConfig.ResourceLock, client); reason != "" {
+			klog.Warningf("Coordinated leader election is unavailable, %s, falling back to the classic election", reason)
+			coordinated = false
+		} else {
+			if err := validateLeaseCandidateName(id); err != nil {
+				return err
+			}
+			klog.Infof("Coordinated leader election requires RBAC on %s", describeRules([]rbacv1.PolicyRule{leaseCandidateRule}))
+		}
+	}
 
-	if len(LeaderElectionConfig.ResourceNamespace) == 0 {
-		return fmt.Errorf("namespace may not be empty")
+	if opts.Quorum != nil {
+		klog.Infof("Campaigning for a majority of %d clusters", len(opts.Quorum.Clusters))
 	}
 
-	if len(LeaderElectionConfig.ResourceName) == 0 {
-		return fmt.Errorf("name may not be empty")
+	// The lock is given no recorder: the Events client-go records on
+	// leadership changes would duplicate ours.
+	events := newEvents(opts.EventRecorder, client, LeaderElectionConfig.ResourceLock, LeaderElectionConfig.ResourceNamespace)
+	if events != nil {
+		klog.Infof("Events require RBAC on %s", describeRules([]rbacv1.PolicyRule{eventRule}))
 	}
 
-	lock, err := resourcelock.New(
+	metadata := localMetadata(id, opts.AdvertiseAddress)
+
+	newCandidate := func(name string) (*candidate, error) {
+		var (
+			lock   Backend
+			quorum *quorumLock
+			err    error
+		)
+		if opts.Quorum != nil {
+			quorum, err = opts.Quorum.lock(LeaderElectionConfig.ResourceLock, LeaderElectionConfig.ResourceNamespace, name, rlc, opts.Metrics)
+			lock = quorum
+		} else {
+			lock, err = NewBackend(
+				LeaderElectionConfig.ResourceLock,
+				LeaderElectionConfig.ResourceNamespace,
+				name,
+				client,
+				rlc,
+			)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("create leader election lock, err: %v", err)
+		}
+		// client-go renews a lease held under our own identity right away,
+		// which is how a restarted candidate takes its lease back.
+		if record, _, err := lock.Get(ctx); err == nil && record.HolderIdentity == id {
+			klog.Infof("Lease %s is still held by %s, taking it back", lock.Describe(), id)
+		}
+		if coordinated {
+			if err := startLeaseCandidate(ctx, client, LeaderElectionConfig.ResourceNamespace, name, id, opts.BinaryVersion); err != nil {
+				return nil, err
+			}
+		}
+		fencing := &fencingLock{Interface: &metadataLock{Interface: lock, metadata: metadata}, lease: name}
+		c := &candidate{
+			lec: leaderelection.LeaderElectionConfig{
+				Lock:            opts.Metrics.instrument(name, fencing),
+				ReleaseOnCancel: true,
+				LeaseDuration:   LeaderElectionConfig.LeaseDuration.Duration,
+				RenewDeadline:   LeaderElectionConfig.RenewDeadline.Duration,
+				RetryPeriod:     LeaderElectionConfig.RetryPeriod.Duration,
+				Name:            name,
+				Coordinated:     coordinated,
+			},
+			run:           run,
+			metrics:       opts.Metrics,
+			events:        events,
+			onLoss:        opts.OnLoss,
+			fencing:       fencing,
+			fencingStatus: opts.FencingStatus,
+		}
+		c.lec.Lock = events.watch(name, c.lec.Lock, &c.leading)
+		c.lec.Lock = opts.ClockSkew.watch(c.lec.Lock, LeaderElectionConfig.RetryPeriod.Duration)
+		if opts.ClockSkew != nil {
+			c.gates = append(c.gates, opts.ClockSkew.mayCampaign)
+		}
+		if quorum != nil {
+			c.yields = append(c.yields, quorum.shouldYield)
+		}
+		if opts.Preconditions != nil {
+			c.gates = append(c.gates, opts.Preconditions.mayCampaign)
+			c.yields = append(c.yields, func() bool {
+				return opts.Preconditions.shouldYield(opts.Metrics, name)
+			})
+		}
+		c.onNewLeader = append(c.onNewLeader, func(identity string) {
+			opts.Leaders.observe(name, identity)
+		})
+		if opts.Release != nil {
+			opts.Release.attach(c)
+		}
+		return c, nil
+	}
+
+	// Every candidate heartbeats where the backend allows it, so that the
+	// others know who is around and what it publishes. Candidates of the
+	// default priority must heartbeat too, or they could not tell that a
+	// candidate of a higher priority is around.
+	var members *memberTracker
+	if membership, err := NewMembership(
 		LeaderElectionConfig.ResourceLock,
 		LeaderElectionConfig.ResourceNamespace,
 		LeaderElectionConfig.ResourceName,
-		client.CoreV1(),
-		client.CoordinationV1(),
-		resourcelock.ResourceLockConfig{
-			Identity: id,
-		},
-	)
+		client,
+	); err == nil {
+		if UsesKubernetes(LeaderElectionConfig.ResourceLock) {
+			klog.Infof("Membership heartbeats require RBAC on %s", describeRules([]rbacv1.PolicyRule{memberLeaseRule}))
+		}
+		members = newMemberTracker(membership, LeaderElectionConfig.ResourceName, id, LeaderElectionConfig.LeaseDuration.Duration, opts.Metrics)
+		members.setAnnotation(PriorityAnnotation, strconv.Itoa(opts.Priority))
+		members.setAnnotation(VersionAnnotation, opts.BinaryVersion)
+		if opts.AdvertiseAddress != "" {
+			members.setAnnotation(AddressAnnotation, opts.AdvertiseAddress)
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		done := members.start(ctx, LeaderElectionConfig.RetryPeriod.Duration)
+		defer func() {
+			cancel()
+			<-done
+		}()
+	} else if opts.Shards > 0 || opts.Priority != 0 || opts.UpgradeAware {
+		return err
+	}
+
+	opts.Leaders.init(id, members)
+
+	if opts.Shards > 0 {
+		status := opts.ShardStatus
+		if status == nil {
+			status = &ShardStatus{}
+		}
+		return runShards(ctx, LeaderElectionConfig, opts, members, status, newCandidate)
+	}
+
+	c, err := newCandidate(LeaderElectionConfig.ResourceName)
 	if err != nil {
-		return fmt.Errorf("create leader election lock, err: %v", err)
-	}
-
-	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
-		Lock:            lock,
-		ReleaseOnCancel: true,
-		LeaseDuration:   LeaderElectionConfig.LeaseDuration.Duration,
-		RenewDeadline:   LeaderElectionConfig.RenewDeadline.Duration,
-		RetryPeriod:     LeaderElectionConfig.RetryPeriod.Duration,
-		Callbacks: leaderelection.LeaderCallbacks{
-			OnStartedLeading: func(ctx context.Context) {
-				klog.V(1).Info("Started leading")
-				run()
-			},
-			OnStoppedLeading: func() {
-				klog.V(1).Info("Leader lost")
-			},
-			OnNewLeader: func(identity string) {
-				// Just got the lock
-				if identity == id {
-					return
-				}
-				klog.V(1).Infof("New leader elected: %v", identity)
-			},
-		},
+		return err
+	}
+	c.lec.WatchDog = opts.WatchDog
+	if members != nil {
+		self := rank{priority: opts.Priority}
+		if opts.UpgradeAware {
+			if self.version, err = utilversion.ParseGeneric(opts.BinaryVersion); err != nil {
+				return fmt.Errorf("parse binary version, err: %w", err)
+			}
+		}
+		p := &priority{
+			self:     self,
+			identity: id,
+			window:   opts.PriorityStabilizationWindow,
+			members:  members,
+			metrics:  opts.Metrics,
+			election: LeaderElectionConfig.ResourceName,
+		}
+		c.defers = append(c.defers, p.mayCampaign)
+		c.yields = append(c.yields, p.shouldYield)
+		c.onEligible = func(eligible bool) {
+			members.setAnnotation(EligibleAnnotation, strconv.FormatBool(eligible))
+		}
+		if opts.UpgradeAware {
+			u := &upgrade{
+				self:     self,
+				identity: id,
+				members:  members,
+				metrics:  opts.Metrics,
+				election: LeaderElectionConfig.ResourceName,
+			}
+			c.defers = append(c.defers, u.mayCampaign)
+			c.yields = append(c.yields, u.shouldYield)
+		}
+	}
+	return c.loop(ctx)
+}
+
+// candidate campaigns for a single lock.
+type candidate struct {
+	lec     leaderelection.LeaderElectionConfig
+	run     func(ctx context.Context)
+	metrics *Metrics
+	events  *events
+	onLoss  LossPolicy
+
+	// fencing hands out the fencing token of every term, fencingStatus
+	// publishes it.
+	fencing       *fencingLock
+	fencingStatus *FencingStatus
+
+	// gates keep the candidate out of the race while any of them returns
+	// false.
+	gates []func() bool
+	// defers keep the candidate out of the race in favour of other
+	// candidates while any of them returns false. Unlike gates, they do not
+	// make the candidate ineligible.
+	defers []func() bool
+	// onEligible, when set, is told whether the gates let the candidate
+	// campaign every time they are evaluated, so that others only defer to
+	// candidates that may take the lease.
+	onEligible func(eligible bool)
+	// yields are polled while leading. Once any of them returns true the
+	// lease is released and the candidate goes back to campaigning.
+	yields []func() bool
+	// onNewLeader are told about every leader observed.
+	onNewLeader []func(identity string)
+
+	leading atomic.Bool
+}
+
+// loop campaigns term after term until ctx is done or leadership is lost
+// and the loss policy says to stop.
+func (c *candidate) loop(ctx context.Context) error {
+	for {
+		if !c.waitToCampaign(ctx) {
+			return nil
+		}
+
+		yielded, err := c.campaign(ctx)
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			// Shutting down, not a loss.
+			return nil
+		}
+		if yielded {
+			klog.V(1).Infof("Stepped out of the race for lease %s", c.lec.Lock.Describe())
+			continue
+		}
+
+		switch c.onLoss {
+		case LossPolicyExit:
+			return nil
+		case LossPolicyExitError:
+			return ErrLeadershipLost
+		default:
+			klog.Infof("Leadership lost, campaigning again for lease %s", c.lec.Lock.Describe())
+		}
+	}
+}
+
+// mayCampaign reports whether every gate lets the candidate campaign, and
+// no defer asks it to leave the lease to another candidate.
+func (c *candidate) mayCampaign() bool {
+	eligible := c.eligible()
+	if c.onEligible != nil {
+		c.onEligible(eligible)
+	}
+	if !eligible {
+		return false
+	}
+	for _, deferring := range c.defers {
+		if !deferring() {
+			return false
+		}
+	}
+	return true
+}
+
+// eligible reports whether every gate lets the candidate campaign.
+func (c *candidate) eligible() bool {
+	for _, gate := range c.gates {
+		if !gate() {
+			return false
+		}
+	}
+	return true
+}
+
+// shouldYield reports whether any yield asks the leader to step down.
+func (c *candidate) shouldYield() bool {
+	for _, yield := range c.yields {
+		if yield() {
+			return true
+		}
+	}
+	return false
+}
+
+// waitToCampaign blocks until the gates allow campaigning. It returns false
+// if ctx is done first.
+func (c *candidate) waitToCampaign(ctx context.Context) bool {
+	err := wait.PollUntilContextCancel(ctx, c.lec.RetryPeriod, true, func(context.Context) (bool, error) {
+		return c.mayCampaign(), nil
 	})
-	return nil
+	return err == nil
+}
+
+// campaign runs a single term: it waits for leadership, runs the workload
+// with a fresh leadership context and returns once leadership is lost or ctx
+// is done. yielded reports that the term was ended on purpose, because a
+// yield asked the leader to step down or a gate closed before leadership
+// was acquired.
+func (c *candidate) campaign(ctx context.Context) (yielded bool, err error) {
+	lec := c.lec
+	id := lec.Lock.Identity()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var stepDown atomic.Bool
+	if len(c.gates) > 0 || len(c.defers) > 0 || len(c.yields) > 0 {
+		go wait.Until(func() {
+			leading := c.leading.Load()
+			if (leading && c.shouldYield()) || (!leading && !c.mayCampaign()) {
+				stepDown.Store(true)
+				cancel()
+			}
+		}, lec.RetryPeriod, ctx.Done())
+	}
+
+	// client-go starts the workload in a goroutine, which may not have run
+	// by the time leadership is lost. Whether the lease was acquired is
+	// known from the lock instead, and stopped keeps a workload started
+	// that late from running at all.
+	lock := &acquiredLock{Interface: lec.Lock}
+	lec.Lock = lock
+	var mu sync.Mutex
+	var stopped bool
+	workloadDone := make(chan struct{})
+
+	lec.Callbacks = leaderelection.LeaderCallbacks{
+		OnStartedLeading: func(ctx context.Context) {
+			defer close(workloadDone)
+			mu.Lock()
+			if stopped {
+				mu.Unlock()
+				return
+			}
+			klog.V(1).Infof("Started leading %s", lec.Name)
+			c.leading.Store(true)
+			c.metrics.leading(lec.Name, true)
+			c.events.acquired(lec.Name, id)
+			token := c.fencing.token()
+			klog.V(1).Infof("Fencing token of lease %s is %s", lec.Name, token)
+			c.fencingStatus.set(lec.Name, token, true)
+			mu.Unlock()
+			c.run(WithFencingToken(ctx, token))
+		},
+		OnStoppedLeading: func() {
+			mu.Lock()
+			stopped = true
+			klog.V(1).Infof("Leader lost %s", lec.Name)
+			c.leading.Store(false)
+			c.metrics.leading(lec.Name, false)
+			c.fencingStatus.set(lec.Name, FencingToken{}, false)
+			mu.Unlock()
+			if !lock.acquired.Load() {
+				return
+			}
+			if ctx.Err() != nil {
+				c.events.released(lec.Name, id)
+			} else {
+				c.events.lost(lec.Name, id)
+			}
+			// The leadership context is already cancelled at this point,
+			// give the workload a chance to finish what it is doing.
+			select {
+			case <-workloadDone:
+			case <-time.After(WorkloadShutdownTimeout):
+				klog.Warningf("Workload did not return within %v after leadership was lost", WorkloadShutdownTimeout)
+			}
+		},
+		OnNewLeader: func(identity string) {
+			c.metrics.transition(lec.Name, identity)
+			for _, onNewLeader := range c.onNewLeader {
+				onNewLeader(identity)
+			}
+			// Just got the lock
+			if identity == id {
+				return
+			}
+			klog.V(1).Infof("New leader elected: %v", identity)
+			c.events.newLeader(lec.Name, id, identity)
+		},
+	}
+
+	le, err := leaderelection.NewLeaderElector(lec)
+	if err != nil {
+		return false, fmt.Errorf("create leader elector, err: %w", err)
+	}
+	if lec.WatchDog != nil {
+		lec.WatchDog.SetLeaderElection(le)
+	}
+	le.Run(ctx)
+	return stepDown.Load(), nil
+}
+
+// acquiredLock records whether the lease was written under our identity,
+// which client-go does before it starts leading.
+type acquiredLock struct {
+	resourcelock.Interface
+	acquired atomic.Bool
+}
+
+func (l *acquiredLock) Create(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	err := l.Interface.Create(ctx, ler)
+	l.observe(ler, err)
+	return err
+}
+
+func (l *acquiredLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	err := l.Interface.Update(ctx, ler)
+	l.observe(ler, err)
+	return err
+}
+
+func (l *acquiredLock) observe(ler resourcelock.LeaderElectionRecord, err error) {
+	if err == nil && ler.HolderIdentity == l.Identity() {
+		l.acquired.Store(true)
+	}
 }
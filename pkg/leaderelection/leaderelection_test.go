@@ -0,0 +1,119 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+// TestLossPolicy makes the leader fail its renewals, and checks that its
+// workload is stopped and that it then campaigns again or returns as its
+// loss policy says.
+func TestLossPolicy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs leader elections")
+	}
+	tests := []struct {
+		policy LossPolicy
+		// wantTerms is the number of terms the candidate leads, and wantErr
+		// what NewLeaderElection returns once the last term is lost.
+		wantTerms int
+		wantErr   error
+	}{
+		{policy: LossPolicyRecampaign, wantTerms: 2},
+		{policy: LossPolicyExit, wantTerms: 1},
+		{policy: LossPolicyExitError, wantTerms: 1, wantErr: ErrLeadershipLost},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			store := DefaultMemoryStore()
+			config := &componentbaseconfig.LeaderElectionConfiguration{
+				LeaderElect:       true,
+				LeaseDuration:     metav1.Duration{Duration: 2 * time.Second},
+				RenewDeadline:     metav1.Duration{Duration: time.Second},
+				RetryPeriod:       metav1.Duration{Duration: 100 * time.Millisecond},
+				ResourceLock:      MemoryResourceLock,
+				ResourceName:      "loss-" + string(tt.policy),
+				ResourceNamespace: "test",
+			}
+			opts := DefaultOptions()
+			opts.Identity = "loss-" + string(tt.policy)
+			opts.OnLoss = tt.policy
+			defer store.FailUpdates(opts.Identity, 0)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			// terms receives the leadership context of every term.
+			terms := make(chan context.Context, 2)
+			done := make(chan error, 1)
+			go func() {
+				done <- NewLeaderElection(func(ctx context.Context) {
+					terms <- ctx
+					<-ctx.Done()
+				}, nil, config, opts, ctx)
+			}()
+
+			for term := 1; term <= tt.wantTerms; term++ {
+				var leading context.Context
+				select {
+				case leading = <-terms:
+				case err := <-done:
+					t.Fatalf("term %d: returned with %v before leading", term, err)
+				case <-time.After(5 * time.Second):
+					t.Fatalf("term %d: did not lead", term)
+				}
+				store.FailUpdates(opts.Identity, 1000)
+				select {
+				case <-leading.Done():
+				case <-time.After(5 * time.Second):
+					t.Fatalf("term %d: the workload was not stopped once the renewals failed", term)
+				}
+				// Let it lead again, unless this is the last term.
+				if term < tt.wantTerms {
+					store.FailUpdates(opts.Identity, 0)
+				}
+			}
+
+			if tt.policy == LossPolicyRecampaign {
+				cancel()
+			}
+			select {
+			case err := <-done:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("NewLeaderElection() = %v, want %v", err, tt.wantErr)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("NewLeaderElection() did not return")
+			}
+			if len(terms) > 0 {
+				t.Errorf("led %d more terms than the %d expected", len(terms), tt.wantTerms)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"sort"
+	"sync"
+)
+
+// AddressAnnotation publishes where a candidate serves HTTP on its
+// heartbeat, host:port.
+const AddressAnnotation = "kle.io/address"
+
+// Leaders tracks the leaders of the leases this candidate campaigns for,
+// and where they serve, so that requests only the leader serves can be
+// sent its way. Its zero value is ready to use.
+type Leaders struct {
+	mu       sync.Mutex
+	identity string
+	members  *memberTracker
+	holders  map[string]string
+}
+
+// Leader returns the identity of a candidate other than this one leading
+// one of the leases, preferring the first lease by name, and the address
+// it published. ok is false if no other candidate leads, or if it did not
+// publish an address.
+func (l *Leaders) Leader() (identity, address string, ok bool) {
+	l.mu.Lock()
+	leases := make([]string, 0, len(l.holders))
+	for lease, holder := range l.holders {
+		if holder != "" && holder != l.identity {
+			leases = append(leases, lease)
+		}
+	}
+	sort.Strings(leases)
+	holders := make([]string, 0, len(leases))
+	for _, lease := range leases {
+		holders = append(holders, l.holders[lease])
+	}
+	members := l.members
+	l.mu.Unlock()
+
+	if len(holders) == 0 {
+		return "", "", false
+	}
+	if members != nil {
+		addresses := map[string]string{}
+		for _, m := range members.Members() {
+			addresses[m.Identity] = m.Annotations[AddressAnnotation]
+		}
+		for _, holder := range holders {
+			if address := addresses[holder]; address != "" {
+				return holder, address, true
+			}
+		}
+	}
+	return holders[0], "", false
+}
+
+func (l *Leaders) init(identity string, members *memberTracker) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.identity = identity
+	l.members = members
+	l.holders = map[string]string{}
+}
+
+func (l *Leaders) observe(lease, holder string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.holders[lease] = holder
+}
@@ -0,0 +1,78 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// lockStep is one call made on one of the locks of a test, with the error it
+// is expected to return.
+type lockStep struct {
+	lock   string
+	op     string
+	holder string
+	want   func(error) bool
+}
+
+var (
+	succeeds      = func(err error) bool { return err == nil }
+	notFound      = apierrors.IsNotFound
+	alreadyExists = apierrors.IsAlreadyExists
+	conflicts     = apierrors.IsConflict
+)
+
+// runLockSteps makes steps on locks, by name, in order. The op of a step is
+// get, create or update, holder being the holder identity written.
+func runLockSteps(t *testing.T, locks map[string]resourcelock.Interface, steps []lockStep) {
+	t.Helper()
+	ctx := context.Background()
+	for i, step := range steps {
+		lock, ok := locks[step.lock]
+		if !ok {
+			t.Fatalf("step %d: no lock %q", i, step.lock)
+		}
+		var err error
+		switch step.op {
+		case "get":
+			var record *resourcelock.LeaderElectionRecord
+			record, _, err = lock.Get(ctx)
+			if err == nil && step.holder != "" && record.HolderIdentity != step.holder {
+				t.Errorf("step %d: %s got holder %q, want %q", i, step.lock, record.HolderIdentity, step.holder)
+			}
+		case "create":
+			err = lock.Create(ctx, resourcelock.LeaderElectionRecord{HolderIdentity: step.holder})
+		case "update":
+			err = lock.Update(ctx, resourcelock.LeaderElectionRecord{HolderIdentity: step.holder})
+		default:
+			t.Fatalf("step %d: unknown op %q", i, step.op)
+		}
+		if !step.want(err) {
+			t.Errorf("step %d: %s %s returned unexpected error %v", i, step.lock, step.op, err)
+		}
+	}
+}
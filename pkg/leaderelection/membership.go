@@ -0,0 +1,319 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/klog/v2"
+)
+
+// ElectionLabel labels the heartbeat Leases of the candidates of an
+// election with the election's resource name.
+const ElectionLabel = "kle.io/election"
+
+// EligibleAnnotation publishes on its heartbeat whether a candidate may
+// campaign as far as it is concerned, "true" or "false". A candidate kept
+// out of the race, e.g. cooling down after a release or failing its
+// preconditions, publishes "false" so that the others do not defer to it.
+const EligibleAnnotation = "kle.io/eligible"
+
+// Member is a candidate taking part in an election.
+type Member struct {
+	Identity  string
+	RenewTime time.Time
+	// Annotations are published by the candidate along with its heartbeat,
+	// e.g. PriorityAnnotation.
+	Annotations map[string]string
+}
+
+// Eligible reports whether the member may campaign, as it published. A
+// member that did not publish it is assumed to.
+func (m Member) Eligible() bool {
+	return m.Annotations[EligibleAnnotation] != "false"
+}
+
+// Membership tracks the candidates taking part in an election through
+// heartbeats, whether they lead or not.
+type Membership interface {
+	// Heartbeat records that member is alive, along with its annotations.
+	Heartbeat(ctx context.Context, member Member) error
+	// Leave removes identity, so that the others notice it is gone without
+	// waiting for its heartbeat to expire.
+	Leave(ctx context.Context, identity string) error
+	// Members returns the candidates whose last heartbeat is younger than
+	// ttl.
+	Members(ctx context.Context, ttl time.Duration) ([]Member, error)
+}
+
+// MembershipFunc creates the Membership of the election called election in
+// namespace.
+type MembershipFunc func(namespace, election string, client clientset.Interface) (Membership, error)
+
+// NewMembership creates the Membership matching the given resource lock
+// type.
+func NewMembership(lockType, namespace, election string, client clientset.Interface) (Membership, error) {
+	b, ok := backends[lockType]
+	if !ok {
+		return nil, invalidLockTypeError(lockType)
+	}
+	if b.members == nil {
+		return nil, fmt.Errorf("resource lock %q does not support membership", lockType)
+	}
+	return b.members(namespace, election, client)
+}
+
+// newLeaseMembership keeps one heartbeat Lease per candidate, whichever
+// Kubernetes lock the election itself uses.
+func newLeaseMembership(namespace, election string, client clientset.Interface) (Membership, error) {
+	if client == nil {
+		return nil, fmt.Errorf("lease membership needs a kubernetes client")
+	}
+	return &LeaseMembership{
+		Client:    client.CoordinationV1(),
+		Namespace: namespace,
+		Election:  election,
+	}, nil
+}
+
+// LeaseMembership keeps a heartbeat Lease per candidate, labelled with
+// ElectionLabel and held by the candidate's identity.
+type LeaseMembership struct {
+	Client    coordinationv1client.LeasesGetter
+	Namespace string
+	Election  string
+}
+
+// Heartbeat creates or renews the heartbeat Lease of member.
+func (lm *LeaseMembership) Heartbeat(ctx context.Context, member Member) error {
+	now := metav1.NewMicroTime(time.Now())
+	identity := member.Identity
+	leases := lm.Client.Leases(lm.Namespace)
+
+	lease, err := leases.Get(ctx, lm.leaseName(identity), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        lm.leaseName(identity),
+				Namespace:   lm.Namespace,
+				Labels:      map[string]string{ElectionLabel: lm.Election},
+				Annotations: member.Annotations,
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity: &identity,
+				RenewTime:      &now,
+			},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	lease.Annotations = member.Annotations
+	lease.Spec.HolderIdentity = &identity
+	lease.Spec.RenewTime = &now
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	return err
+}
+
+// Leave deletes the heartbeat Lease of identity.
+func (lm *LeaseMembership) Leave(ctx context.Context, identity string) error {
+	err := lm.Client.Leases(lm.Namespace).Delete(ctx, lm.leaseName(identity), metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// Members lists the heartbeat Leases renewed within ttl.
+func (lm *LeaseMembership) Members(ctx context.Context, ttl time.Duration) ([]Member, error) {
+	list, err := lm.Client.Leases(lm.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{ElectionLabel: lm.Election}).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var members []Member
+	for _, lease := range list.Items {
+		if lease.Spec.HolderIdentity == nil || lease.Spec.RenewTime == nil {
+			continue
+		}
+		if time.Since(lease.Spec.RenewTime.Time) > ttl {
+			continue
+		}
+		members = append(members, Member{
+			Identity:    *lease.Spec.HolderIdentity,
+			RenewTime:   lease.Spec.RenewTime.Time,
+			Annotations: lease.Annotations,
+		})
+	}
+	return members, nil
+}
+
+// leaseName derives a valid object name from identity, which may contain
+// characters names may not.
+func (lm *LeaseMembership) leaseName(identity string) string {
+	hash := sha256.Sum256([]byte(identity))
+	return fmt.Sprintf("%s-member-%x", lm.Election, hash[:5])
+}
+
+// memoryMembership keeps the heartbeats in a MemoryStore.
+type memoryMembership struct {
+	store *MemoryStore
+	key   string
+}
+
+func newMemoryMembership(namespace, election string, _ clientset.Interface) (Membership, error) {
+	return &memoryMembership{store: defaultMemoryStore, key: memoryKey(namespace, election)}, nil
+}
+
+func (mm *memoryMembership) Heartbeat(_ context.Context, member Member) error {
+	mm.store.mu.Lock()
+	defer mm.store.mu.Unlock()
+	if mm.store.members[mm.key] == nil {
+		mm.store.members[mm.key] = map[string]Member{}
+	}
+	member.RenewTime = time.Now()
+	mm.store.members[mm.key][member.Identity] = member
+	return nil
+}
+
+func (mm *memoryMembership) Leave(_ context.Context, identity string) error {
+	mm.store.mu.Lock()
+	defer mm.store.mu.Unlock()
+	delete(mm.store.members[mm.key], identity)
+	return nil
+}
+
+func (mm *memoryMembership) Members(_ context.Context, ttl time.Duration) ([]Member, error) {
+	mm.store.mu.Lock()
+	defer mm.store.mu.Unlock()
+	var members []Member
+	for _, member := range mm.store.members[mm.key] {
+		if time.Since(member.RenewTime) > ttl {
+			continue
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// memberTracker heartbeats on behalf of this candidate and keeps the last
+// observed members around.
+type memberTracker struct {
+	membership Membership
+	election   string
+	ttl        time.Duration
+	metrics    *Metrics
+
+	mu      sync.Mutex
+	self    Member
+	members []Member
+}
+
+func newMemberTracker(membership Membership, election, identity string, ttl time.Duration, metrics *Metrics) *memberTracker {
+	return &memberTracker{
+		membership: membership,
+		election:   election,
+		ttl:        ttl,
+		metrics:    metrics,
+		self:       Member{Identity: identity, Annotations: map[string]string{}},
+	}
+}
+
+// setAnnotation publishes key with the next heartbeat, an empty value
+// removing it.
+func (t *memberTracker) setAnnotation(key, value string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	annotations := maps.Clone(t.self.Annotations)
+	if len(value) == 0 {
+		delete(annotations, key)
+	} else {
+		annotations[key] = value
+	}
+	t.self.Annotations = annotations
+}
+
+// Members returns the members observed by the last heartbeat, this
+// candidate included.
+func (t *memberTracker) Members() []Member {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.members
+}
+
+// heartbeat publishes this candidate and refreshes the members.
+func (t *memberTracker) heartbeat(ctx context.Context) {
+	t.mu.Lock()
+	self := t.self
+	t.mu.Unlock()
+
+	if err := t.membership.Heartbeat(ctx, self); err != nil {
+		klog.Errorf("Failed to heartbeat election %s: %v", t.election, err)
+	}
+	members, err := t.membership.Members(ctx, t.ttl)
+	if err != nil {
+		klog.Errorf("Failed to list members of election %s: %v", t.election, err)
+		return
+	}
+
+	t.mu.Lock()
+	t.members = members
+	t.mu.Unlock()
+	t.metrics.membersObserved(t.election, len(members))
+}
+
+// start heartbeats every period until ctx is done, then leaves the
+// election and closes the returned channel. The first heartbeat is done
+// before start returns, so that callers know about the other members from
+// the start.
+func (t *memberTracker) start(ctx context.Context, period time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	t.heartbeat(ctx)
+	go func() {
+		defer close(done)
+		wait.Until(func() { t.heartbeat(ctx) }, period, ctx.Done())
+
+		leaveCtx, cancel := context.WithTimeout(context.Background(), period)
+		defer cancel()
+		if err := t.membership.Leave(leaveCtx, t.self.Identity); err != nil {
+			klog.Errorf("Failed to leave election %s: %v", t.election, err)
+		}
+	}()
+	return done
+}
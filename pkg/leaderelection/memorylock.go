@@ -0,0 +1,243 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// MemoryResourceLock campaigns on a record kept in the memory of the
+// process. Every candidate in the process using it competes on the same
+// DefaultMemoryStore, which is meant for tests and demos.
+const MemoryResourceLock = "memory"
+
+var memoryResource = schema.GroupResource{Resource: "memory"}
+
+// ErrInjectedFailure is returned by MemoryLock updates failed on purpose
+// through MemoryStore.FailUpdates.
+var ErrInjectedFailure = errors.New("injected failure")
+
+var defaultMemoryStore = NewMemoryStore()
+
+// DefaultMemoryStore returns the store used by the memory resource lock.
+func DefaultMemoryStore() *MemoryStore {
+	return defaultMemoryStore
+}
+
+// MemoryStore holds leader election records in memory. Every write bumps
+// the version of the record, and writes based on a stale version are
+// rejected with a conflict, the same way the API server treats a Lease
+// resourceVersion.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]memoryRecord
+	// members holds the membership heartbeats by election.
+	members map[string]map[string]Member
+	// failUpdates and renewDelay are the injected faults by identity, the
+	// empty identity applying to every candidate.
+	failUpdates map[string]int
+	renewDelay  map[string]time.Duration
+}
+
+type memoryRecord struct {
+	version uint64
+	record  resourcelock.LeaderElectionRecord
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records:     map[string]memoryRecord{},
+		members:     map[string]map[string]Member{},
+		failUpdates: map[string]int{},
+		renewDelay:  map[string]time.Duration{},
+	}
+}
+
+// FailUpdates makes the next n updates, creates included, made by identity
+// fail with ErrInjectedFailure. An empty identity matches every candidate.
+func (s *MemoryStore) FailUpdates(identity string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failUpdates[identity] = n
+}
+
+// DelayRenewals delays every renewal made by identity by d, until it is
+// reset with a zero d. An empty identity matches every candidate.
+func (s *MemoryStore) DelayRenewals(identity string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d == 0 {
+		delete(s.renewDelay, identity)
+		return
+	}
+	s.renewDelay[identity] = d
+}
+
+// Record returns the record stored under namespace and name.
+func (s *MemoryStore) Record(namespace, name string) (resourcelock.LeaderElectionRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.records[memoryKey(namespace, name)]
+	return r.record, ok
+}
+
+// injectedFault consumes an injected update failure for identity, if any.
+// The caller must hold s.mu.
+func (s *MemoryStore) injectedFault(identity string) error {
+	for _, id := range []string{identity, ""} {
+		if s.failUpdates[id] > 0 {
+			s.failUpdates[id]--
+			return ErrInjectedFailure
+		}
+	}
+	return nil
+}
+
+// renewalDelay returns how long a renewal made by identity is delayed.
+func (s *MemoryStore) renewalDelay(identity string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d, ok := s.renewDelay[identity]; ok {
+		return d
+	}
+	return s.renewDelay[""]
+}
+
+func memoryKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// MemoryLock is a lock on a record of a MemoryStore.
+type MemoryLock struct {
+	Store      *MemoryStore
+	Namespace  string
+	Name       string
+	LockConfig resourcelock.ResourceLockConfig
+	// version is the version observed by the last Get, Create or Update.
+	version uint64
+}
+
+// NewMemoryBackend returns a lock on the record called name in namespace of
+// store.
+func NewMemoryBackend(store *MemoryStore, namespace, name string, rlc resourcelock.ResourceLockConfig) Backend {
+	return &MemoryLock{Store: store, Namespace: namespace, Name: name, LockConfig: rlc}
+}
+
+func newMemoryBackend(namespace, name string, _ clientset.Interface, rlc resourcelock.ResourceLockConfig) (Backend, error) {
+	return NewMemoryBackend(defaultMemoryStore, namespace, name, rlc), nil
+}
+
+// Get returns the election record from the store.
+func (ml *MemoryLock) Get(_ context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	ml.Store.mu.Lock()
+	defer ml.Store.mu.Unlock()
+
+	r, ok := ml.Store.records[ml.key()]
+	if !ok {
+		return nil, nil, apierrors.NewNotFound(memoryResource, ml.Describe())
+	}
+	recordBytes, err := json.Marshal(r.record)
+	if err != nil {
+		return nil, nil, err
+	}
+	ml.version = r.version
+	record := r.record
+	return &record, recordBytes, nil
+}
+
+// Create attempts to create the election record in the store.
+func (ml *MemoryLock) Create(_ context.Context, ler resourcelock.LeaderElectionRecord) error {
+	ml.Store.mu.Lock()
+	defer ml.Store.mu.Unlock()
+
+	if err := ml.Store.injectedFault(ml.Identity()); err != nil {
+		return err
+	}
+	if _, ok := ml.Store.records[ml.key()]; ok {
+		return apierrors.NewAlreadyExists(memoryResource, ml.Describe())
+	}
+	ml.Store.records[ml.key()] = memoryRecord{version: 1, record: ler}
+	ml.version = 1
+	return nil
+}
+
+// Update will update the election record in the store, renewals being
+// delayed as configured with MemoryStore.DelayRenewals.
+func (ml *MemoryLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	if ler.HolderIdentity == ml.Identity() {
+		if d := ml.Store.renewalDelay(ml.Identity()); d > 0 {
+			select {
+			case <-time.After(d):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	ml.Store.mu.Lock()
+	defer ml.Store.mu.Unlock()
+
+	if err := ml.Store.injectedFault(ml.Identity()); err != nil {
+		return err
+	}
+	r, ok := ml.Store.records[ml.key()]
+	if !ok {
+		return apierrors.NewNotFound(memoryResource, ml.Describe())
+	}
+	if r.version != ml.version {
+		return apierrors.NewConflict(memoryResource, ml.Describe(), errors.New("the record has been modified since it was read"))
+	}
+	r.version++
+	r.record = ler
+	ml.Store.records[ml.key()] = r
+	ml.version = r.version
+	return nil
+}
+
+// RecordEvent is a no-op, there is no object to record events on.
+func (ml *MemoryLock) RecordEvent(string) {}
+
+// Describe is used to convert details on current resource lock
+// into a string
+func (ml *MemoryLock) Describe() string {
+	return ml.key()
+}
+
+// Identity returns the Identity of the lock
+func (ml *MemoryLock) Identity() string {
+	return ml.LockConfig.Identity
+}
+
+func (ml *MemoryLock) key() string {
+	return memoryKey(ml.Namespace, ml.Name)
+}
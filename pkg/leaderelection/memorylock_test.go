@@ -0,0 +1,173 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func TestMemoryLock(t *testing.T) {
+	injected := func(err error) bool { return errors.Is(err, ErrInjectedFailure) }
+	tests := []struct {
+		name  string
+		setup func(s *MemoryStore)
+		steps []lockStep
+	}{
+		{
+			name: "get without record",
+			steps: []lockStep{
+				{lock: "a", op: "get", want: notFound},
+			},
+		},
+		{
+			name: "update without record",
+			steps: []lockStep{
+				{lock: "a", op: "update", holder: "a", want: notFound},
+			},
+		},
+		{
+			name: "create twice",
+			steps: []lockStep{
+				{lock: "a", op: "create", holder: "a", want: succeeds},
+				{lock: "b", op: "create", holder: "b", want: alreadyExists},
+			},
+		},
+		{
+			name: "renew",
+			steps: []lockStep{
+				{lock: "a", op: "create", holder: "a", want: succeeds},
+				{lock: "a", op: "update", holder: "a", want: succeeds},
+				{lock: "b", op: "get", holder: "a", want: succeeds},
+			},
+		},
+		{
+			name: "stale update",
+			steps: []lockStep{
+				{lock: "a", op: "create", holder: "a", want: succeeds},
+				{lock: "b", op: "get", holder: "a", want: succeeds},
+				{lock: "a", op: "update", holder: "a", want: succeeds},
+				{lock: "b", op: "update", holder: "b", want: conflicts},
+				{lock: "b", op: "get", holder: "a", want: succeeds},
+				{lock: "b", op: "update", holder: "b", want: succeeds},
+			},
+		},
+		{
+			name:  "failure injected for identity",
+			setup: func(s *MemoryStore) { s.FailUpdates("a", 2) },
+			steps: []lockStep{
+				{lock: "a", op: "create", holder: "a", want: injected},
+				{lock: "b", op: "create", holder: "b", want: succeeds},
+				{lock: "a", op: "get", holder: "b", want: succeeds},
+				{lock: "a", op: "update", holder: "a", want: injected},
+				{lock: "a", op: "update", holder: "a", want: succeeds},
+			},
+		},
+		{
+			name:  "failure injected for everyone",
+			setup: func(s *MemoryStore) { s.FailUpdates("", 1) },
+			steps: []lockStep{
+				{lock: "b", op: "create", holder: "b", want: injected},
+				{lock: "a", op: "create", holder: "a", want: succeeds},
+			},
+		},
+		{
+			name:  "failure injected for another identity",
+			setup: func(s *MemoryStore) { s.FailUpdates("b", 1) },
+			steps: []lockStep{
+				{lock: "a", op: "create", holder: "a", want: succeeds},
+				{lock: "a", op: "update", holder: "a", want: succeeds},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			if tt.setup != nil {
+				tt.setup(store)
+			}
+			locks := map[string]resourcelock.Interface{}
+			for _, id := range []string{"a", "b"} {
+				locks[id] = NewMemoryBackend(store, "demo", "kle", resourcelock.ResourceLockConfig{Identity: id})
+			}
+			runLockSteps(t, locks, tt.steps)
+		})
+	}
+}
+
+func TestMemoryLockDelayRenewals(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	tests := []struct {
+		name     string
+		delayFor string
+		holder   string
+		// cancel cancels the context of the update before it is made.
+		cancel    bool
+		wantDelay bool
+		wantErr   error
+	}{
+		{name: "renewal of delayed identity", delayFor: "a", holder: "a", wantDelay: true},
+		{name: "renewal delayed for everyone", delayFor: "", holder: "a", wantDelay: true},
+		{name: "renewal of another identity", delayFor: "b", holder: "a"},
+		{name: "write that is not a renewal", delayFor: "a", holder: "b"},
+		{name: "cancelled renewal", delayFor: "a", holder: "a", cancel: true, wantErr: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			lock := NewMemoryBackend(store, "demo", "kle", resourcelock.ResourceLockConfig{Identity: "a"})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := lock.Create(ctx, resourcelock.LeaderElectionRecord{HolderIdentity: "a"}); err != nil {
+				t.Fatal(err)
+			}
+			store.DelayRenewals(tt.delayFor, delay)
+			if tt.cancel {
+				cancel()
+			}
+
+			start := time.Now()
+			err := lock.Update(ctx, resourcelock.LeaderElectionRecord{HolderIdentity: tt.holder})
+			elapsed := time.Since(start)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if delayed := elapsed >= delay; delayed != tt.wantDelay {
+				t.Errorf("update took %v, want delayed %v", elapsed, tt.wantDelay)
+			}
+
+			store.DelayRenewals(tt.delayFor, 0)
+			start = time.Now()
+			if err := lock.Update(context.Background(), resourcelock.LeaderElectionRecord{HolderIdentity: "a"}); err != nil && tt.wantErr == nil {
+				t.Fatal(err)
+			}
+			if elapsed := time.Since(start); elapsed >= delay {
+				t.Errorf("update took %v once the delay was reset", elapsed)
+			}
+		})
+	}
+}
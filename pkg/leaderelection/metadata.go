@@ -0,0 +1,214 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/yshngg/kle/pkg/version"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// The annotations the leader publishes its LeaderMetadata in, on the object
+// of its lock.
+const (
+	LeaderIdentityAnnotation     = "kle.io/leader-identity"
+	LeaderVersionAnnotation      = "kle.io/leader-version"
+	LeaderAddressAnnotation      = "kle.io/leader-address"
+	LeaderPodAnnotation          = "kle.io/leader-pod"
+	LeaderNodeAnnotation         = "kle.io/leader-node"
+	LeaderStartTimeAnnotation    = "kle.io/leader-start-time"
+	LeaderFencingEpochAnnotation = "kle.io/leader-fencing-epoch"
+)
+
+var leaderAnnotationKeys = []string{
+	LeaderIdentityAnnotation,
+	LeaderVersionAnnotation,
+	LeaderAddressAnnotation,
+	LeaderPodAnnotation,
+	LeaderNodeAnnotation,
+	LeaderStartTimeAnnotation,
+	LeaderFencingEpochAnnotation,
+}
+
+// LeaderMetadata describes the leader of a lease beyond its identity. Fields
+// the leader does not know are left empty.
+type LeaderMetadata struct {
+	// Identity is the holder the metadata was published by.
+	Identity string `json:"identity"`
+	// Version is the kle version of the leader.
+	Version string `json:"version,omitempty"`
+	// Address is where the leader serves HTTP, host:port.
+	Address string `json:"address,omitempty"`
+	// Pod is the Pod the leader runs in, <namespace>/<name>.
+	Pod string `json:"pod,omitempty"`
+	// Node is the node the leader runs on.
+	Node string `json:"node,omitempty"`
+	// StartTime is when the term of the leader started.
+	StartTime metav1.Time `json:"startTime,omitempty"`
+	// FencingEpoch is the epoch of the fencing token of the term.
+	FencingEpoch uint32 `json:"fencingEpoch,omitempty"`
+}
+
+// LeaderMetadataFromObject reads the LeaderMetadata published on obj. ok is
+// false if there is none, or if it was published by another holder than
+// holder, as happens when a candidate that does not publish any takes over.
+func LeaderMetadataFromObject(obj metav1.Object, holder string) (metadata LeaderMetadata, ok bool) {
+	annotations := obj.GetAnnotations()
+	metadata.Identity = annotations[LeaderIdentityAnnotation]
+	if metadata.Identity == "" || metadata.Identity != holder {
+		return LeaderMetadata{}, false
+	}
+	metadata.Version = annotations[LeaderVersionAnnotation]
+	metadata.Address = annotations[LeaderAddressAnnotation]
+	metadata.Pod = annotations[LeaderPodAnnotation]
+	metadata.Node = annotations[LeaderNodeAnnotation]
+	if t, err := time.Parse(time.RFC3339, annotations[LeaderStartTimeAnnotation]); err == nil {
+		metadata.StartTime = metav1.NewTime(t)
+	}
+	if epoch, err := strconv.ParseUint(annotations[LeaderFencingEpochAnnotation], 10, 32); err == nil {
+		metadata.FencingEpoch = uint32(epoch)
+	}
+	return metadata, true
+}
+
+// GetLeaderMetadata reads the LeaderMetadata of the current holder of the
+// Lease name in namespace.
+func GetLeaderMetadata(ctx context.Context, client coordinationv1client.LeasesGetter, namespace, name string) (LeaderMetadata, bool, error) {
+	lease, err := client.Leases(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return LeaderMetadata{}, false, err
+	}
+	var holder string
+	if lease.Spec.HolderIdentity != nil {
+		holder = *lease.Spec.HolderIdentity
+	}
+	metadata, ok := LeaderMetadataFromObject(lease, holder)
+	return metadata, ok, nil
+}
+
+// annotations formats m as the annotations it is published in.
+func (m LeaderMetadata) annotations() map[string]string {
+	annotations := map[string]string{
+		LeaderIdentityAnnotation:  m.Identity,
+		LeaderVersionAnnotation:   m.Version,
+		LeaderAddressAnnotation:   m.Address,
+		LeaderPodAnnotation:       m.Pod,
+		LeaderNodeAnnotation:      m.Node,
+		LeaderStartTimeAnnotation: m.StartTime.UTC().Format(time.RFC3339),
+	}
+	if m.FencingEpoch != 0 {
+		annotations[LeaderFencingEpochAnnotation] = strconv.FormatUint(uint64(m.FencingEpoch), 10)
+	}
+	for key, value := range annotations {
+		if value == "" {
+			delete(annotations, key)
+		}
+	}
+	return annotations
+}
+
+// applyLeaderAnnotations replaces the leader annotations in annotations
+// with leader, creating annotations if need be.
+func applyLeaderAnnotations(annotations, leader map[string]string) map[string]string {
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	for _, key := range leaderAnnotationKeys {
+		delete(annotations, key)
+	}
+	for key, value := range leader {
+		annotations[key] = value
+	}
+	return annotations
+}
+
+// leaderAnnotator is implemented by locks which publish annotations along
+// with the leader election record.
+type leaderAnnotator interface {
+	setLeaderAnnotations(annotations map[string]string)
+}
+
+func setLeaderAnnotations(lock resourcelock.Interface, annotations map[string]string) {
+	switch l := lock.(type) {
+	case leaderAnnotator:
+		l.setLeaderAnnotations(annotations)
+	case *resourcelock.MultiLock:
+		setLeaderAnnotations(l.Primary, annotations)
+		setLeaderAnnotations(l.Secondary, annotations)
+	}
+}
+
+// localMetadata returns what this candidate publishes about itself as
+// leader, the term aside.
+func localMetadata(identity, address string) LeaderMetadata {
+	metadata := LeaderMetadata{
+		Identity: identity,
+		Version:  version.Get().GitVersion,
+		Address:  address,
+		Node:     os.Getenv(NodeNameEnv),
+	}
+	if name := os.Getenv(PodNameEnv); name != "" {
+		metadata.Pod = name
+		if namespace := os.Getenv(PodNamespaceEnv); namespace != "" {
+			metadata.Pod = namespace + "/" + name
+		}
+	}
+	return metadata
+}
+
+// metadataLock publishes the LeaderMetadata along with every write of the
+// lock under our identity, so that it is kept current on renew, and clears
+// it when the lease is released. The term is read from the fencing token in
+// the context of the write.
+type metadataLock struct {
+	resourcelock.Interface
+	metadata LeaderMetadata
+}
+
+func (l *metadataLock) Create(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	setLeaderAnnotations(l.Interface, l.annotations(ctx, ler))
+	return l.Interface.Create(ctx, ler)
+}
+
+func (l *metadataLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	setLeaderAnnotations(l.Interface, l.annotations(ctx, ler))
+	return l.Interface.Update(ctx, ler)
+}
+
+func (l *metadataLock) annotations(ctx context.Context, ler resourcelock.LeaderElectionRecord) map[string]string {
+	if ler.HolderIdentity != l.Identity() {
+		return nil
+	}
+	metadata := l.metadata
+	metadata.StartTime = ler.AcquireTime
+	if token, ok := FencingTokenFromContext(ctx); ok {
+		metadata.FencingEpoch = token.Epoch
+	}
+	return metadata.annotations()
+}
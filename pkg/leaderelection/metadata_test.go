@@ -0,0 +1,111 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	fakeclient "github.com/yshngg/kle/pkg/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// TestMetadataLock publishes the metadata of a leader on a Lease, and
+// checks that it is read back only while that leader holds it.
+func TestMetadataLock(t *testing.T) {
+	ctx := context.Background()
+	client, err := fakeclient.Kubernetes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newLock := func(identity string) resourcelock.Interface {
+		lock, err := NewBackend(LeasesResourceLock, "demo", "kle", client, resourcelock.ResourceLockConfig{Identity: identity})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lock
+	}
+	metadata := LeaderMetadata{Identity: "a", Version: "v1.0.0", Address: "10.0.0.1:2190", Pod: "demo/kle-0", Node: "node-0"}
+	a := &metadataLock{Interface: newLock("a"), metadata: metadata}
+	b := newLock("b")
+	leaderMetadata := func() (LeaderMetadata, bool) {
+		t.Helper()
+		got, ok, err := GetLeaderMetadata(ctx, client.CoordinationV1(), "demo", "kle")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got, ok
+	}
+
+	acquired := metav1.NewTime(time.Now().Truncate(time.Second))
+	token := FencingToken{Lease: "kle", Transitions: 0, Epoch: 42}
+	if err := a.Create(WithFencingToken(ctx, token), resourcelock.LeaderElectionRecord{HolderIdentity: "a", AcquireTime: acquired}); err != nil {
+		t.Fatal(err)
+	}
+	want := metadata
+	want.StartTime, want.FencingEpoch = acquired, 42
+	got, ok := leaderMetadata()
+	if !ok || !got.StartTime.Equal(&want.StartTime) {
+		t.Fatalf("GetLeaderMetadata() = %+v, %v, want %+v", got, ok, want)
+	}
+	got.StartTime = want.StartTime
+	if got != want {
+		t.Errorf("GetLeaderMetadata() = %+v, want %+v", got, want)
+	}
+
+	// Another holder that publishes nothing takes over.
+	if _, _, err := b.Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Update(ctx, resourcelock.LeaderElectionRecord{HolderIdentity: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := leaderMetadata(); ok {
+		t.Errorf("GetLeaderMetadata() = %+v published by a while b holds the lease", got)
+	}
+
+	// a takes the lease back and releases it, which clears its metadata.
+	if _, _, err := a.Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Update(ctx, resourcelock.LeaderElectionRecord{HolderIdentity: "a", AcquireTime: acquired}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := leaderMetadata(); !ok {
+		t.Error("no metadata once a took the lease back")
+	}
+	if err := a.Update(ctx, resourcelock.LeaderElectionRecord{}); err != nil {
+		t.Fatal(err)
+	}
+	lease, err := client.CoordinationV1().Leases("demo").Get(ctx, "kle", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range leaderAnnotationKeys {
+		if value, ok := lease.Annotations[key]; ok {
+			t.Errorf("annotation %s=%s left on the released lease", key, value)
+		}
+	}
+}
@@ -0,0 +1,436 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	metricsNamespace = "kle"
+	metricsSubsystem = "leader_election"
+)
+
+// Metrics holds the leader election metrics of a kle process. Every
+// candidate reports them, whether it leads or not. All methods are safe to
+// call on a nil *Metrics, which records nothing.
+type Metrics struct {
+	isLeader        *prometheus.GaugeVec
+	transitions     *prometheus.CounterVec
+	renewDuration   *prometheus.HistogramVec
+	acquireAttempts *prometheus.CounterVec
+	acquireFailures *prometheus.CounterVec
+	lastRenew       *renewCollector
+	shardsOwned     *prometheus.GaugeVec
+	stepDowns       *prometheus.CounterVec
+	members         *prometheus.GaugeVec
+	clockSkew       *prometheus.GaugeVec
+	upgrades        *prometheus.CounterVec
+	quorumHolds     *prometheus.GaugeVec
+	quorumErrors    *prometheus.CounterVec
+
+	// masterStatus and slowpath back the client-go metrics provider.
+	masterStatus *prometheus.GaugeVec
+	slowpath     *prometheus.CounterVec
+
+	mu sync.Mutex
+	// holders is the last leader observed per lease, so that a transition
+	// is only counted when it changes.
+	holders map[string]string
+}
+
+// NewMetrics creates the leader election metrics, registers them with reg
+// and installs them as the client-go leader election metrics provider. Only
+// the first provider installed in a process is used by client-go.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		isLeader: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: metricsNamespace,
+				Subsystem: metricsSubsystem,
+				Name:      "is_leader",
+				Help:      "Whether this candidate currently leads the lease, 1 if it does and 0 otherwise.",
+			}, []string{"lease"},
+		),
+		transitions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: metricsNamespace,
+				Subsystem: metricsSubsystem,
+				Name:      "transitions_total",
+				Help:      "Number of leadership changes observed by this candidate.",
+			}, []string{"lease"},
+		),
+		renewDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: metricsNamespace,
+				Subsystem: metricsSubsystem,
+				Name:      "renew_duration_seconds",
+				Help:      "Latency of lease renewals made by the leader.",
+				Buckets:   prometheus.DefBuckets,
+			}, []string{"lease"},
+		),
+		acquireAttempts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: metricsNamespace,
+				Subsystem: metricsSubsystem,
+				Name:      "acquire_attempts_total",
+				Help:      "Number of attempts to acquire the lease.",
+			}, []string{"lease"},
+		),
+		acquireFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: metricsNamespace,
+				Subsystem: metricsSubsystem,
+				Name:      "acquire_failures_total",
+				Help:      "Number of attempts to acquire the lease that did not succeed.",
+			}, []string{"lease"},
+		),
+		lastRenew: &renewCollector{
+			desc: prometheus.NewDesc(
+				prometheus.BuildFQName(metricsNamespace, metricsSubsystem, "seconds_since_last_renew"),
+				"Seconds since the lease was last successfully renewed, as observed by this candidate.",
+				[]string{"lease"}, nil,
+			),
+			renewed: map[string]time.Time{},
+		},
+		shardsOwned: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: metricsNamespace,
+				Subsystem: metricsSubsystem,
+				Name:      "shard_owned",
+				Help:      "Whether this candidate currently owns the shard of a sharded election, 1 if it does and 0 otherwise.",
+			}, []string{"lease", "shard"},
+		),
+		stepDowns: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: metricsNamespace,
+				Subsystem: metricsSubsystem,
+				Name:      "step_downs_total",
+				Help:      "Number of times this candidate released leadership on purpose, by reason.",
+			}, []string{"lease", "reason"},
+		),
+		members: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: metricsNamespace,
+				Subsystem: metricsSubsystem,
+				Name:      "members",
+				Help:      "Number of live candidates taking part in the election, as observed by this candidate.",
+			}, []string{"lease"},
+		),
+		clockSkew: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: metricsNamespace,
+				Subsystem: metricsSubsystem,
+				Name:      "clock_skew_seconds",
+				Help:      "Estimated offset of another clock from the local one, positive when it is ahead. 'source' is the API server or the holder of a lease.",
+			}, []string{"source"},
+		),
+		upgrades: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: metricsNamespace,
+				Subsystem: metricsSubsystem,
+				Name:      "upgrade_decisions_total",
+				Help:      "Number of decisions taken by upgrade-aware leadership, by decision: defer to a newer candidate, resume campaigning or yield to a newer candidate.",
+			}, []string{"lease", "decision"},
+		),
+		quorumHolds: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: metricsNamespace,
+				Subsystem: metricsSubsystem,
+				Name:      "quorum_cluster_held",
+				Help:      "Whether this candidate holds the lease in the cluster of a quorum election, 1 if it does and 0 otherwise.",
+			}, []string{"lease", "cluster"},
+		),
+		quorumErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: metricsNamespace,
+				Subsystem: metricsSubsystem,
+				Name:      "quorum_cluster_errors_total",
+				Help:      "Number of failed reads and writes of the lease in the cluster of a quorum election.",
+			}, []string{"lease", "cluster"},
+		),
+		masterStatus: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "leader_election_master_status",
+				Help: "Gauge of if the reporting system is master of the relevant lease, 0 indicates backup, 1 indicates master. 'name' is the string used to identify the lease.",
+			}, []string{"name"},
+		),
+		slowpath: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "leader_election_slowpath_total",
+				Help: "Total number of slow path exercised in renewing leader leases. 'name' is the string used to identify the lease.",
+			}, []string{"name"},
+		),
+		holders: map[string]string{},
+	}
+
+	reg.MustRegister(
+		m.isLeader,
+		m.transitions,
+		m.renewDuration,
+		m.acquireAttempts,
+		m.acquireFailures,
+		m.lastRenew,
+		m.shardsOwned,
+		m.stepDowns,
+		m.members,
+		m.clockSkew,
+		m.upgrades,
+		m.quorumHolds,
+		m.quorumErrors,
+		m.masterStatus,
+		m.slowpath,
+	)
+	leaderelection.SetProvider(m)
+	return m
+}
+
+// NewLeaderMetric implements leaderelection.MetricsProvider.
+func (m *Metrics) NewLeaderMetric() leaderelection.LeaderMetric {
+	return &leaderMetric{metrics: m}
+}
+
+// leaderMetric reports the client-go leader election metrics.
+type leaderMetric struct {
+	metrics *Metrics
+}
+
+func (l *leaderMetric) On(name string) {
+	l.metrics.masterStatus.WithLabelValues(name).Set(1)
+}
+
+func (l *leaderMetric) Off(name string) {
+	l.metrics.masterStatus.WithLabelValues(name).Set(0)
+}
+
+func (l *leaderMetric) SlowpathExercised(name string) {
+	l.metrics.slowpath.WithLabelValues(name).Inc()
+}
+
+func (m *Metrics) leading(lease string, leading bool) {
+	if m == nil {
+		return
+	}
+	if leading {
+		m.isLeader.WithLabelValues(lease).Set(1)
+		return
+	}
+	m.isLeader.WithLabelValues(lease).Set(0)
+}
+
+// transition counts holder leading lease if it is a new leader. The lease
+// being released, with no holder, and the same leader observed again, as
+// by each term of a candidate, are not transitions.
+func (m *Metrics) transition(lease, holder string) {
+	if m == nil || holder == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.holders[lease] == holder {
+		return
+	}
+	m.holders[lease] = holder
+	m.transitions.WithLabelValues(lease).Inc()
+}
+
+func (m *Metrics) shardOwned(lease string, shard int, owned bool) {
+	if m == nil {
+		return
+	}
+	if owned {
+		m.shardsOwned.WithLabelValues(lease, strconv.Itoa(shard)).Set(1)
+		return
+	}
+	m.shardsOwned.WithLabelValues(lease, strconv.Itoa(shard)).Set(0)
+}
+
+func (m *Metrics) stepDown(lease, reason string) {
+	if m == nil {
+		return
+	}
+	m.stepDowns.WithLabelValues(lease, reason).Inc()
+}
+
+func (m *Metrics) membersObserved(lease string, members int) {
+	if m == nil {
+		return
+	}
+	m.members.WithLabelValues(lease).Set(float64(members))
+}
+
+func (m *Metrics) clockSkewObserved(source string, skew time.Duration) {
+	if m == nil {
+		return
+	}
+	m.clockSkew.WithLabelValues(source).Set(skew.Seconds())
+}
+
+func (m *Metrics) upgradeDecision(lease, decision string) {
+	if m == nil {
+		return
+	}
+	m.upgrades.WithLabelValues(lease, decision).Inc()
+}
+
+func (m *Metrics) quorumHeld(lease, cluster string, held bool) {
+	if m == nil {
+		return
+	}
+	if held {
+		m.quorumHolds.WithLabelValues(lease, cluster).Set(1)
+		return
+	}
+	m.quorumHolds.WithLabelValues(lease, cluster).Set(0)
+}
+
+func (m *Metrics) quorumError(lease, cluster string) {
+	if m == nil {
+		return
+	}
+	m.quorumErrors.WithLabelValues(lease, cluster).Inc()
+}
+
+// instrument wraps lock so that lock operations are reported under lease.
+func (m *Metrics) instrument(lease string, lock resourcelock.Interface) resourcelock.Interface {
+	if m == nil {
+		return lock
+	}
+	m.isLeader.WithLabelValues(lease).Set(0)
+	m.transitions.WithLabelValues(lease)
+	m.acquireAttempts.WithLabelValues(lease)
+	m.acquireFailures.WithLabelValues(lease)
+	return &instrumentedLock{Interface: lock, lease: lease, metrics: m}
+}
+
+// renewCollector reports the seconds since each lease was last renewed.
+type renewCollector struct {
+	desc *prometheus.Desc
+
+	mu      sync.Mutex
+	renewed map[string]time.Time
+}
+
+func (c *renewCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *renewCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for lease, renewed := range c.renewed {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, time.Since(renewed).Seconds(), lease)
+	}
+}
+
+func (c *renewCollector) observe(lease string, renewed time.Time) {
+	if renewed.IsZero() {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.renewed[lease] = renewed
+}
+
+// instrumentedLock reports acquire attempts, renew latencies and renew times
+// of the wrapped lock. Candidates that do not hold the lease go through Get
+// on every retry, so each Get made while not leading counts as an attempt.
+type instrumentedLock struct {
+	resourcelock.Interface
+	lease   string
+	metrics *Metrics
+
+	mu   sync.Mutex
+	held bool
+}
+
+func (l *instrumentedLock) Get(ctx context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	ler, raw, err := l.Interface.Get(ctx)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if ler != nil {
+		l.metrics.lastRenew.observe(l.lease, ler.RenewTime.Time)
+		if ler.HolderIdentity != l.Identity() {
+			l.held = false
+		}
+	}
+	if l.held {
+		return ler, raw, err
+	}
+
+	l.metrics.acquireAttempts.WithLabelValues(l.lease).Inc()
+	switch {
+	case err != nil && !errors.IsNotFound(err):
+		l.metrics.acquireFailures.WithLabelValues(l.lease).Inc()
+	case ler != nil && len(ler.HolderIdentity) > 0 && ler.HolderIdentity != l.Identity() &&
+		ler.RenewTime.Add(time.Duration(ler.LeaseDurationSeconds)*time.Second).After(time.Now()):
+		// Held by someone else and not yet expired, client-go gives up.
+		l.metrics.acquireFailures.WithLabelValues(l.lease).Inc()
+	}
+	return ler, raw, err
+}
+
+func (l *instrumentedLock) Create(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	err := l.Interface.Create(ctx, ler)
+	l.record(ler, err, 0)
+	return err
+}
+
+func (l *instrumentedLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	start := time.Now()
+	err := l.Interface.Update(ctx, ler)
+	l.record(ler, err, time.Since(start))
+	return err
+}
+
+func (l *instrumentedLock) record(ler resourcelock.LeaderElectionRecord, err error, took time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if ler.HolderIdentity != l.Identity() {
+		// Releasing the lease.
+		if err == nil {
+			l.held = false
+		}
+		return
+	}
+
+	if l.held {
+		l.metrics.renewDuration.WithLabelValues(l.lease).Observe(took.Seconds())
+	} else if err != nil {
+		l.metrics.acquireFailures.WithLabelValues(l.lease).Inc()
+	}
+	if err == nil {
+		l.held = true
+		l.metrics.lastRenew.observe(l.lease, ler.RenewTime.Time)
+	}
+}
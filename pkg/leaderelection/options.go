@@ -0,0 +1,168 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yshngg/kle/pkg/version"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/record"
+)
+
+// ErrLeadershipLost is returned by NewLeaderElection when leadership is lost
+// and the loss policy is LossPolicyExitError.
+var ErrLeadershipLost = errors.New("leadership lost")
+
+// LossPolicy decides what a candidate does once it has lost leadership.
+type LossPolicy string
+
+const (
+	// LossPolicyRecampaign makes the candidate campaign again with a fresh
+	// leadership context.
+	LossPolicyRecampaign LossPolicy = "recampaign"
+	// LossPolicyExit makes NewLeaderElection return nil.
+	LossPolicyExit LossPolicy = "exit"
+	// LossPolicyExitError makes NewLeaderElection return ErrLeadershipLost.
+	LossPolicyExitError LossPolicy = "exit-error"
+)
+
+// String implements pflag.Value.
+func (p *LossPolicy) String() string {
+	return string(*p)
+}
+
+// Set implements pflag.Value.
+func (p *LossPolicy) Set(s string) error {
+	switch LossPolicy(s) {
+	case LossPolicyRecampaign, LossPolicyExit, LossPolicyExitError:
+		*p = LossPolicy(s)
+		return nil
+	default:
+		return fmt.Errorf("unknown loss policy %q, must be one of %q, %q or %q", s, LossPolicyRecampaign, LossPolicyExit, LossPolicyExitError)
+	}
+}
+
+// Type implements pflag.Value.
+func (p *LossPolicy) Type() string {
+	return "string"
+}
+
+// Options holds the kle specific leader election settings which are not part
+// of componentbaseconfig.LeaderElectionConfiguration.
+type Options struct {
+	// Identity is the identity the candidate holds leases under. When empty
+	// it is read from IdentityFile, taken from the Downward API or made up.
+	Identity string
+	// IdentityFile, when set, persists the identity across restarts.
+	IdentityFile string
+
+	// OnLoss decides what happens once leadership is lost.
+	OnLoss LossPolicy
+	// HealthzTimeout is how long the leader may go without renewing its lease,
+	// beyond the lease duration, before the watchdog reports it unhealthy.
+	HealthzTimeout time.Duration
+
+	// Priority is published by the candidate. Candidates defer to live
+	// candidates of a higher priority, and a leader steps down once one has
+	// been around for PriorityStabilizationWindow.
+	Priority int
+	// PriorityStabilizationWindow is how long a candidate of a higher
+	// priority must be around before the leader steps down for it.
+	PriorityStabilizationWindow time.Duration
+	// Shards is the number of leases spread across the candidates, called
+	// <resource name>-<index>. 0 disables sharding.
+	Shards int
+	// Coordinated makes the candidate advertise itself as a LeaseCandidate
+	// and follow the holder the API server picks, instead of racing for the
+	// lease. It falls back to the classic election where the API server does
+	// not serve LeaseCandidates.
+	Coordinated bool
+	// BinaryVersion is the version advertised by the LeaseCandidate, and
+	// published to the other candidates.
+	BinaryVersion string
+	// UpgradeAware makes candidates defer to live candidates running a
+	// newer BinaryVersion, and a leader running an older one step down for
+	// them. Sharded elections do not use it.
+	UpgradeAware bool
+	// AdvertiseAddress is where the candidate serves HTTP, host:port. It is
+	// published to the other candidates.
+	AdvertiseAddress string
+	// MaxClockSkew is how far the local clock may be off the API server's
+	// for the candidate to campaign. 0 disables the limit.
+	MaxClockSkew time.Duration
+	// PreconditionGracePeriod is how long the preconditions of a leader may
+	// fail before it releases its leases.
+	PreconditionGracePeriod time.Duration
+	// ReleaseCoolDown is how long a candidate stays out of the race after
+	// a release was requested through Release.
+	ReleaseCoolDown time.Duration
+
+	// WatchDog, when set, is attached to every leader election term so that
+	// it can be installed as a healthz check. Sharded elections do not use
+	// it. It is not bound to a flag.
+	WatchDog *leaderelection.HealthzAdaptor
+	// ShardStatus, when set, tracks the shards of a sharded election so
+	// that they can be served over HTTP. It is not bound to a flag.
+	ShardStatus *ShardStatus
+	// Release, when set, lets the candidate be asked to release its
+	// leases. It is not bound to a flag.
+	Release *Release
+	// EventRecorder, when set, records leadership changes as Events. It is
+	// not bound to a flag.
+	EventRecorder record.EventRecorder
+	// FencingStatus, when set, tracks the fencing tokens of the terms led
+	// so that they can be served over HTTP. It is not bound to a flag.
+	FencingStatus *FencingStatus
+	// Leaders, when set, tracks the leaders and where they serve. It is not
+	// bound to a flag.
+	Leaders *Leaders
+	// ClockSkew, when set, estimates the skew of the local clock, and keeps
+	// the candidate out of the race while it is beyond its Max. It is not
+	// bound to a flag.
+	ClockSkew *ClockSkew
+	// Quorum, when set, spreads leader election across its clusters, the
+	// lease must be held in a majority of them. It is not bound to a flag.
+	Quorum *Quorum
+	// Preconditions, when set, holds the checks a candidate must pass to
+	// lead. It is not bound to a flag.
+	Preconditions *Preconditions
+	// Metrics, when set, records the leader election metrics. It is not
+	// bound to a flag.
+	Metrics *Metrics
+}
+
+// DefaultOptions returns the default kle specific leader election options.
+func DefaultOptions() *Options {
+	return &Options{
+		OnLoss:                      LossPolicyRecampaign,
+		HealthzTimeout:              20 * time.Second,
+		PriorityStabilizationWindow: 30 * time.Second,
+		BinaryVersion:               version.Get().SemVer(),
+		ReleaseCoolDown:             30 * time.Second,
+		MaxClockSkew:                5 * time.Second,
+		PreconditionGracePeriod:     30 * time.Second,
+	}
+}
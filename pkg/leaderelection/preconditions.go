@@ -0,0 +1,176 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/klog/v2"
+)
+
+// preconditionTimeout bounds a single run of the preconditions.
+const preconditionTimeout = 5 * time.Second
+
+// preconditionReuse is how long the result of a run is reused, so that the
+// shards of a candidate, which all ask every retry period, share one run.
+const preconditionReuse = time.Second
+
+// Preconditions holds the named checks a candidate must pass to lead. A
+// candidate failing any of them does not campaign, and a leader failing
+// them for GracePeriod releases its leases so that a healthy candidate takes
+// over. Checks are the ones /healthz and /readyz use, they are handed a GET
+// request for /. Its zero value is ready to use and has no checks.
+type Preconditions struct {
+	// GracePeriod is how long the checks of a leader may fail before it
+	// releases its leases.
+	GracePeriod time.Duration
+
+	mu     sync.Mutex
+	checks []healthz.HealthChecker
+	// failingSince is when the checks started failing for the leader, zero
+	// while they pass.
+	failingSince time.Time
+
+	// runMu serializes the runs, so that callers asking at once wait for a
+	// single run. ranAt is when the last one ended and failed its result.
+	runMu  sync.Mutex
+	ranAt  time.Time
+	failed []string
+}
+
+// Register adds checks. It is not safe to call once leader election runs.
+func (p *Preconditions) Register(checks ...healthz.HealthChecker) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checks = append(p.checks, checks...)
+}
+
+// Checks returns the registered checks, renamed precondition-<name>, to be
+// installed on /readyz.
+func (p *Preconditions) Checks() []healthz.HealthChecker {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	checks := make([]healthz.HealthChecker, 0, len(p.checks))
+	for _, check := range p.checks {
+		checks = append(checks, healthz.NamedCheck("precondition-"+check.Name(), check.Check))
+	}
+	return checks
+}
+
+// failing runs the checks, all at once under a single deadline, and returns
+// the names of those that failed. A run that ended less than
+// preconditionReuse ago is not repeated.
+func (p *Preconditions) failing() []string {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+	if !p.ranAt.IsZero() && time.Since(p.ranAt) < preconditionReuse {
+		return p.failed
+	}
+
+	p.mu.Lock()
+	checks := p.checks
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), preconditionTimeout)
+	defer cancel()
+	type result struct {
+		check int
+		err   error
+	}
+	// Buffered, so that a check still running past the deadline does not
+	// leak its goroutine.
+	results := make(chan result, len(checks))
+	for i, check := range checks {
+		go func() {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+			if err == nil {
+				err = check.Check(req)
+			}
+			results <- result{check: i, err: err}
+		}()
+	}
+	errs := make([]error, len(checks))
+	for i := range errs {
+		errs[i] = context.DeadlineExceeded
+	}
+	// Checks that did not answer by the deadline have failed.
+	timedOut := false
+	for received := 0; received < len(checks) && !timedOut; received++ {
+		select {
+		case r := <-results:
+			errs[r.check] = r.err
+		case <-ctx.Done():
+			timedOut = true
+		}
+	}
+
+	var failed []string
+	for i, err := range errs {
+		if err != nil {
+			klog.V(2).Infof("Precondition %s failed, err: %v", checks[i].Name(), err)
+			failed = append(failed, checks[i].Name())
+		}
+	}
+	p.ranAt, p.failed = time.Now(), failed
+	return failed
+}
+
+// mayCampaign keeps the candidate out of the race while any check fails.
+func (p *Preconditions) mayCampaign() bool {
+	failed := p.failing()
+	if len(failed) > 0 {
+		klog.V(1).Infof("Not campaigning, preconditions failing: %s", strings.Join(failed, ", "))
+	}
+	return len(failed) == 0
+}
+
+// shouldYield asks the leader to step down once its checks have been
+// failing for the grace period.
+func (p *Preconditions) shouldYield(metrics *Metrics, election string) bool {
+	failed := p.failing()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(failed) == 0 {
+		p.failingSince = time.Time{}
+		return false
+	}
+	if p.failingSince.IsZero() {
+		p.failingSince = time.Now()
+		klog.Warningf("Preconditions failing: %s, stepping down in %v unless they recover", strings.Join(failed, ", "), p.GracePeriod)
+	}
+	if time.Since(p.failingSince) < p.GracePeriod {
+		return false
+	}
+
+	// failingSince is left alone, so that every term steps down until the
+	// checks pass again.
+	klog.Infof("Stepping down from %s, preconditions failing: %s", election, strings.Join(failed, ", "))
+	metrics.stepDown(election, "precondition")
+	return true
+}
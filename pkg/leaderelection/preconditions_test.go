@@ -0,0 +1,75 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/server/healthz"
+)
+
+// TestPreconditionsFailing runs slow checks for several shards at once, and
+// checks that they run concurrently and once for all the shards.
+func TestPreconditionsFailing(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	var runs atomic.Int32
+	check := func(name string, err error) healthz.HealthChecker {
+		return healthz.NamedCheck(name, func(*http.Request) error {
+			runs.Add(1)
+			time.Sleep(delay)
+			return err
+		})
+	}
+	p := &Preconditions{}
+	p.Register(check("a", nil), check("b", errors.New("down")), check("c", nil))
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	results := make([][]string, 4)
+	for shard := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[shard] = p.failing()
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed > 2*delay {
+		t.Errorf("checks took %v, want them to run concurrently within %v", elapsed, 2*delay)
+	}
+	if got := runs.Load(); got != 3 {
+		t.Errorf("checks ran %d times, want each of them once", got)
+	}
+	for shard, failed := range results {
+		if fmt.Sprint(failed) != "[b]" {
+			t.Errorf("shard %d: failing() = %v, want [b]", shard, failed)
+		}
+	}
+}
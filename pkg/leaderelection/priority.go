@@ -0,0 +1,150 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"cmp"
+	"strconv"
+	"sync"
+	"time"
+
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/klog/v2"
+)
+
+// PriorityAnnotation publishes the priority of a candidate on its heartbeat.
+const PriorityAnnotation = "kle.io/priority"
+
+// rank orders candidates. Upgrade aware candidates rank by version first,
+// so that leadership moves forward during a rolling update whatever the
+// priorities, and by priority among candidates running the same version.
+// A version is nil unless upgrade awareness is enabled.
+type rank struct {
+	version  *utilversion.Version
+	priority int
+}
+
+// memberRank returns the rank m published, its version only if versioned.
+// An unparsable priority ranks as the default one, and an unparsable
+// version is not compared.
+func memberRank(m Member, versioned bool) rank {
+	var r rank
+	r.priority, _ = strconv.Atoi(m.Annotations[PriorityAnnotation])
+	if versioned {
+		r.version, _ = utilversion.ParseGeneric(m.Annotations[VersionAnnotation])
+	}
+	return r
+}
+
+// compare returns -1, 0 or +1 as r ranks below, with or above o. It is the
+// only ordering of candidates, so that whoever ranks highest never defers
+// and somebody always campaigns.
+func (r rank) compare(o rank) int {
+	if r.version != nil && o.version != nil {
+		switch {
+		case r.version.GreaterThan(o.version):
+			return 1
+		case r.version.LessThan(o.version):
+			return -1
+		}
+	}
+	return cmp.Compare(r.priority, o.priority)
+}
+
+// outranking returns a live candidate other than identity that outranks
+// self, may campaign and satisfies match, and its rank, if any. Candidates
+// kept out of the race are ignored, lest nobody campaigns.
+func outranking(members *memberTracker, identity string, self rank, match func(rank) bool) (Member, rank, bool) {
+	for _, m := range members.Members() {
+		if m.Identity == identity || !m.Eligible() {
+			continue
+		}
+		r := memberRank(m, self.version != nil)
+		if self.compare(r) < 0 && match(r) {
+			return m, r, true
+		}
+	}
+	return Member{}, rank{}, false
+}
+
+// priority makes candidates defer to healthy candidates of a higher
+// priority, and a leader of a lower priority step down once such a
+// candidate has been around for the stabilization window.
+type priority struct {
+	self     rank
+	identity string
+	window   time.Duration
+	members  *memberTracker
+	metrics  *Metrics
+	election string
+
+	mu sync.Mutex
+	// higherSince is when the leader first saw a higher priority candidate,
+	// zero if it sees none.
+	higherSince time.Time
+}
+
+// higher returns a live candidate with a priority higher than ours that
+// outranks us and may campaign, if any. A candidate running an older
+// version does not outrank us whatever its priority.
+func (p *priority) higher() (Member, int, bool) {
+	m, r, ok := outranking(p.members, p.identity, p.self, func(r rank) bool {
+		return r.priority > p.self.priority
+	})
+	return m, r.priority, ok
+}
+
+// mayCampaign keeps the candidate out of the race while a candidate of a
+// higher priority is alive and may campaign.
+func (p *priority) mayCampaign() bool {
+	m, prio, ok := p.higher()
+	if ok {
+		klog.V(4).Infof("Deferring to candidate %s of priority %d, ours is %d", m.Identity, prio, p.self.priority)
+	}
+	return !ok
+}
+
+// shouldYield asks the leader to step down once a candidate of a higher
+// priority has been alive for the whole stabilization window.
+func (p *priority) shouldYield() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m, prio, ok := p.higher()
+	if !ok {
+		p.higherSince = time.Time{}
+		return false
+	}
+	if p.higherSince.IsZero() {
+		p.higherSince = time.Now()
+		klog.Infof("Candidate %s of priority %d is available, ours is %d, stepping down in %v unless it goes away", m.Identity, prio, p.self.priority, p.window)
+	}
+	if time.Since(p.higherSince) < p.window {
+		return false
+	}
+
+	klog.Infof("Stepping down from %s in favour of candidate %s of priority %d", p.election, m.Identity, prio)
+	p.higherSince = time.Time{}
+	p.metrics.stepDown(p.election, "priority")
+	return true
+}
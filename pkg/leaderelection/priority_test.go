@@ -0,0 +1,183 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+)
+
+func priorityMember(identity, priority string, eligible bool) Member {
+	m := Member{Identity: identity, Annotations: map[string]string{}}
+	if priority != "" {
+		m.Annotations[PriorityAnnotation] = priority
+	}
+	if !eligible {
+		m.Annotations[EligibleAnnotation] = "false"
+	}
+	return m
+}
+
+func TestPriorityMayCampaign(t *testing.T) {
+	tests := []struct {
+		name    string
+		self    int
+		members []Member
+		want    bool
+	}{
+		{name: "alone", self: 0, members: []Member{priorityMember("a", "0", true)}, want: true},
+		{
+			name:    "default priority defers to a higher one",
+			self:    0,
+			members: []Member{priorityMember("a", "", true), priorityMember("b", "5", true)},
+			want:    false,
+		},
+		{
+			name:    "lower priorities are ignored",
+			self:    5,
+			members: []Member{priorityMember("a", "5", true), priorityMember("b", "1", true)},
+			want:    true,
+		},
+		{
+			name:    "equal priorities campaign",
+			self:    5,
+			members: []Member{priorityMember("a", "5", true), priorityMember("b", "5", true)},
+			want:    true,
+		},
+		{
+			name:    "ineligible higher priority is ignored",
+			self:    0,
+			members: []Member{priorityMember("a", "0", true), priorityMember("b", "5", false)},
+			want:    true,
+		},
+		{
+			name:    "unparsable priority is ignored",
+			self:    0,
+			members: []Member{priorityMember("a", "0", true), priorityMember("b", "high", true)},
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &priority{
+				self:     rank{priority: tt.self},
+				identity: "a",
+				members:  &memberTracker{members: tt.members},
+				election: "kle",
+			}
+			if got := p.mayCampaign(); got != tt.want {
+				t.Errorf("mayCampaign() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriorityShouldYield(t *testing.T) {
+	members := &memberTracker{members: []Member{priorityMember("a", "0", true), priorityMember("b", "5", true)}}
+	p := &priority{identity: "a", window: 50 * time.Millisecond, members: members, election: "kle"}
+
+	if p.shouldYield() {
+		t.Fatalf("shouldYield() = true before the stabilization window passed")
+	}
+	time.Sleep(p.window)
+	if !p.shouldYield() {
+		t.Fatalf("shouldYield() = false after the stabilization window passed")
+	}
+
+	// The window starts over once the higher priority candidate goes away.
+	members.members = members.members[:1]
+	if p.shouldYield() {
+		t.Fatalf("shouldYield() = true without a higher priority candidate")
+	}
+	members.members = append(members.members, priorityMember("b", "5", true))
+	if p.shouldYield() {
+		t.Fatalf("shouldYield() = true as soon as the higher priority candidate is back")
+	}
+}
+
+func rankedMember(identity, version, priority string) Member {
+	m := priorityMember(identity, priority, true)
+	m.Annotations[VersionAnnotation] = version
+	return m
+}
+
+// TestPriorityAndUpgrade checks that with both enabled exactly the
+// candidates that rank highest, by version and then by priority, campaign.
+func TestPriorityAndUpgrade(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []Member
+		want    []string
+	}{
+		{
+			name:    "newer version beats higher priority",
+			members: []Member{rankedMember("a", "1.1.0", "0"), rankedMember("b", "1.0.0", "5")},
+			want:    []string{"a"},
+		},
+		{
+			name: "priority decides within a version",
+			members: []Member{
+				rankedMember("a", "1.1.0", "0"),
+				rankedMember("b", "1.1.0", "5"),
+				rankedMember("c", "1.0.0", "9"),
+			},
+			want: []string{"b"},
+		},
+		{
+			name:    "same version and priority",
+			members: []Member{rankedMember("a", "1.0.0", "1"), rankedMember("b", "1.0.0", "1")},
+			want:    []string{"a", "b"},
+		},
+		{
+			name:    "unknown version ranks by priority",
+			members: []Member{rankedMember("a", "1.1.0", "0"), rankedMember("b", "", "5")},
+			want:    []string{"b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := &memberTracker{members: tt.members}
+			var got []string
+			for _, m := range tt.members {
+				self := rank{priority: memberRank(m, false).priority}
+				self.version, _ = utilversion.ParseGeneric(m.Annotations[VersionAnnotation])
+				p := &priority{self: self, identity: m.Identity, members: members, election: "kle"}
+				campaigns := p.mayCampaign()
+				// A candidate of an unknown version is not upgrade aware.
+				if self.version != nil {
+					u := &upgrade{self: self, identity: m.Identity, members: members, election: "kle"}
+					campaigns = u.mayCampaign() && campaigns
+				}
+				if campaigns {
+					got = append(got, m.Identity)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("campaigning = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
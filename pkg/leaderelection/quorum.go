@@ -0,0 +1,317 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/server/healthz"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+)
+
+// QuorumCluster is a cluster taking part in a quorum election.
+type QuorumCluster struct {
+	Name   string
+	Client clientset.Interface
+}
+
+// Quorum spreads leader election across clusters. The candidate campaigns
+// for the lease in every cluster and leads while it holds it in a strict
+// majority of them, so that there is at most one leader across all of them.
+// A leader that fails to renew a majority steps down right away.
+type Quorum struct {
+	Clusters []QuorumCluster
+
+	mu sync.Mutex
+	// errs holds the error of the last access to each cluster, nil once
+	// it succeeded.
+	errs map[string]error
+}
+
+// Checks returns a quorum-<cluster> check per cluster, failing while the
+// last access to the cluster failed, to be installed on /readyz.
+func (q *Quorum) Checks() []healthz.HealthChecker {
+	checks := make([]healthz.HealthChecker, 0, len(q.Clusters))
+	for _, cluster := range q.Clusters {
+		checks = append(checks, healthz.NamedCheck("quorum-"+cluster.Name, func(*http.Request) error {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			return q.errs[cluster.Name]
+		}))
+	}
+	return checks
+}
+
+func (q *Quorum) observe(cluster string, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.errs == nil {
+		q.errs = map[string]error{}
+	}
+	q.errs[cluster] = err
+}
+
+// lock creates the quorum lock of the lease name in namespace, on a lock of
+// lockType in every cluster.
+func (q *Quorum) lock(lockType, namespace, name string, rlc resourcelock.ResourceLockConfig, metrics *Metrics) (*quorumLock, error) {
+	l := &quorumLock{quorum: q, lease: name, identity: rlc.Identity, metrics: metrics}
+	for _, cluster := range q.Clusters {
+		lock, err := NewBackend(lockType, namespace, name, cluster.Client, rlc)
+		if err != nil {
+			return nil, fmt.Errorf("create lock in cluster %s, err: %w", cluster.Name, err)
+		}
+		l.members = append(l.members, &quorumMember{cluster: cluster.Name, lock: lock})
+		metrics.quorumHeld(name, cluster.Name, false)
+	}
+	return l, nil
+}
+
+// quorumMember is the lock of a quorum lock in one cluster, along with what
+// was last read from it.
+type quorumMember struct {
+	cluster string
+	lock    resourcelock.Interface
+
+	// record is the last record read or written, nil if there is none yet.
+	record *resourcelock.LeaderElectionRecord
+	// missing is set when the lock does not exist in the cluster.
+	missing bool
+	// raw and observed are the last raw record read and when it changed,
+	// which tell when the lease expires without trusting the holder's clock.
+	raw      []byte
+	observed time.Time
+	err      error
+}
+
+// free reports whether anyone may take the lease in this cluster as of now.
+func (m *quorumMember) free(now time.Time) bool {
+	if m.missing {
+		return true
+	}
+	if m.record == nil {
+		return false
+	}
+	return m.record.HolderIdentity == "" ||
+		now.After(m.observed.Add(time.Duration(m.record.LeaseDurationSeconds)*time.Second))
+}
+
+func (m *quorumMember) heldBy(identity string) bool {
+	return !m.missing && m.record != nil && m.record.HolderIdentity == identity
+}
+
+func (m *quorumMember) get(ctx context.Context, now time.Time) {
+	record, raw, err := m.lock.Get(ctx)
+	switch {
+	case apierrors.IsNotFound(err):
+		m.record, m.raw, m.missing, m.err = nil, nil, true, nil
+	case err != nil:
+		m.err = err
+	default:
+		if !bytes.Equal(m.raw, raw) {
+			m.raw = raw
+			m.observed = now
+		}
+		m.record, m.missing, m.err = record, false, nil
+	}
+}
+
+func (m *quorumMember) write(ctx context.Context, ler resourcelock.LeaderElectionRecord, now time.Time) {
+	var err error
+	if m.missing {
+		err = m.lock.Create(ctx, ler)
+	} else {
+		err = m.lock.Update(ctx, ler)
+	}
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.record, m.raw, m.observed, m.missing, m.err = &ler, nil, now, false, nil
+}
+
+// quorumLock holds a lease in a strict majority of clusters. It only ever
+// writes to a cluster where the lease is free or already ours, so a
+// candidate holds at most the clusters nobody else holds, and Get reports
+// the lease held by someone else unless we could hold a majority.
+type quorumLock struct {
+	quorum   *Quorum
+	lease    string
+	identity string
+	metrics  *Metrics
+	members  []*quorumMember
+
+	// lost is set while the last write under our identity reached no
+	// majority.
+	lost atomic.Bool
+}
+
+// each runs fn on every member concurrently and records its outcome.
+func (l *quorumLock) each(fn func(m *quorumMember)) {
+	var wg sync.WaitGroup
+	for _, m := range l.members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(m)
+		}()
+	}
+	wg.Wait()
+	for _, m := range l.members {
+		l.quorum.observe(m.cluster, m.err)
+		if m.err != nil {
+			l.metrics.quorumError(l.lease, m.cluster)
+		}
+		l.metrics.quorumHeld(l.lease, m.cluster, m.err == nil && m.heldBy(l.identity))
+	}
+}
+
+func (l *quorumLock) majority(n int) bool {
+	return 2*n > len(l.members)
+}
+
+// Get reads the lease in every cluster. It returns our own record if we
+// hold the lease somewhere and could hold it in a majority, an empty record
+// if we could take it in a majority, and otherwise the record of the
+// candidate holding it in the most clusters.
+func (l *quorumLock) Get(ctx context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	now := time.Now()
+	l.each(func(m *quorumMember) { m.get(ctx, now) })
+
+	var (
+		ours, free, transitions int
+		own, other              *resourcelock.LeaderElectionRecord
+		holders                 = map[string]int{}
+	)
+	for _, m := range l.members {
+		if m.record != nil {
+			transitions = max(transitions, m.record.LeaderTransitions)
+		}
+		switch {
+		case m.err != nil:
+		case m.heldBy(l.identity):
+			ours++
+			if own == nil || m.record.RenewTime.After(own.RenewTime.Time) {
+				own = m.record
+			}
+		case m.free(now):
+			free++
+		default:
+			holders[m.record.HolderIdentity]++
+			if other == nil || holders[m.record.HolderIdentity] > holders[other.HolderIdentity] {
+				other = m.record
+			}
+		}
+	}
+
+	var record resourcelock.LeaderElectionRecord
+	switch {
+	case l.majority(ours+free) && own != nil:
+		record = *own
+	case l.majority(ours + free):
+		record = resourcelock.LeaderElectionRecord{LeaderTransitions: transitions}
+	case other != nil:
+		record = *other
+	default:
+		return nil, nil, fmt.Errorf("lease %s is reachable in %d of %d clusters, a majority is needed", l.lease, ours+free, len(l.members))
+	}
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &record, raw, nil
+}
+
+// Create writes the lease like Update, Get never reports it missing.
+func (l *quorumLock) Create(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	return l.Update(ctx, ler)
+}
+
+// Update writes ler to every cluster where the lease is free or ours, or
+// only where it is ours when releasing it, and fails unless a majority of
+// the clusters took it.
+func (l *quorumLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	now := time.Now()
+	releasing := ler.HolderIdentity != l.identity
+	var written atomic.Int32
+	l.each(func(m *quorumMember) {
+		if !m.heldBy(l.identity) && (releasing || !m.free(now)) {
+			return
+		}
+		m.write(ctx, ler, now)
+		if m.err == nil {
+			written.Add(1)
+		}
+	})
+
+	if releasing {
+		return nil
+	}
+	if n := int(written.Load()); !l.majority(n) {
+		l.lost.Store(true)
+		return fmt.Errorf("wrote lease %s in %d of %d clusters, a majority is needed", l.lease, n, len(l.members))
+	}
+	l.lost.Store(false)
+	return nil
+}
+
+func (l *quorumLock) RecordEvent(s string) {
+	for _, m := range l.members {
+		m.lock.RecordEvent(s)
+	}
+}
+
+func (l *quorumLock) Describe() string {
+	return l.members[0].lock.Describe()
+}
+
+func (l *quorumLock) Identity() string {
+	return l.identity
+}
+
+func (l *quorumLock) setLeaderAnnotations(annotations map[string]string) {
+	for _, m := range l.members {
+		setLeaderAnnotations(m.lock, annotations)
+	}
+}
+
+// shouldYield asks the leader to step down as soon as it failed to renew a
+// majority, instead of retrying until its renew deadline while a candidate
+// may take over the clusters it lost.
+func (l *quorumLock) shouldYield() bool {
+	if !l.lost.Load() {
+		return false
+	}
+	klog.Warningf("Stepping down from %s, it is no longer held in a majority of %d clusters", l.lease, len(l.members))
+	l.metrics.stepDown(l.lease, "quorum")
+	return true
+}
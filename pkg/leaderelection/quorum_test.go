@@ -0,0 +1,217 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// quorumCluster is the state of the lease in one cluster of a quorum test.
+type quorumCluster struct {
+	// holder holds the lease, if set.
+	holder string
+	// expired makes the lease of holder expire.
+	expired bool
+	// failWrites makes our writes fail.
+	failWrites bool
+}
+
+// newQuorumTestLock returns the quorum lock of identity a on a memory store
+// per cluster, set up as clusters says.
+func newQuorumTestLock(t *testing.T, clusters []quorumCluster) (*quorumLock, []*MemoryStore) {
+	t.Helper()
+	q := &Quorum{}
+	l := &quorumLock{quorum: q, lease: "kle", identity: "a"}
+	var stores []*MemoryStore
+	for i, c := range clusters {
+		store := NewMemoryStore()
+		if c.holder != "" {
+			holder := NewMemoryBackend(store, "demo", "kle", resourcelock.ResourceLockConfig{Identity: c.holder})
+			now := time.Now()
+			if err := holder.Create(context.Background(), quorumRecord(c.holder, now)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if c.failWrites {
+			store.FailUpdates("a", 1)
+		}
+		name := fmt.Sprintf("cluster-%d", i)
+		q.Clusters = append(q.Clusters, QuorumCluster{Name: name})
+		l.members = append(l.members, &quorumMember{
+			cluster: name,
+			lock:    NewMemoryBackend(store, "demo", "kle", resourcelock.ResourceLockConfig{Identity: "a"}),
+		})
+		stores = append(stores, store)
+	}
+	return l, stores
+}
+
+func quorumRecord(holder string, now time.Time) resourcelock.LeaderElectionRecord {
+	return resourcelock.LeaderElectionRecord{
+		HolderIdentity:       holder,
+		LeaseDurationSeconds: 15,
+		AcquireTime:          metav1.NewTime(now),
+		RenewTime:            metav1.NewTime(now),
+	}
+}
+
+func TestQuorumLock(t *testing.T) {
+	tests := []struct {
+		name     string
+		clusters []quorumCluster
+		// wantHolder is the holder Get reports.
+		wantHolder string
+		wantErr    bool
+		// wantHeld is the holder of the lease in every cluster once we
+		// tried to take it.
+		wantHeld []string
+	}{
+		{
+			name:     "free everywhere",
+			clusters: []quorumCluster{{}, {}, {}},
+			wantHeld: []string{"a", "a", "a"},
+		},
+		{
+			name:     "free in a majority",
+			clusters: []quorumCluster{{}, {}, {holder: "b"}},
+			wantHeld: []string{"a", "a", "b"},
+		},
+		{
+			name:       "held in a majority",
+			clusters:   []quorumCluster{{holder: "b"}, {holder: "b"}, {}},
+			wantHolder: "b",
+			wantErr:    true,
+			wantHeld:   []string{"b", "b", "a"},
+		},
+		{
+			name:     "held in a majority but expired",
+			clusters: []quorumCluster{{holder: "b", expired: true}, {holder: "b", expired: true}, {}},
+			wantHeld: []string{"a", "a", "a"},
+		},
+		{
+			name:       "split between other holders",
+			clusters:   []quorumCluster{{holder: "b"}, {holder: "c"}, {}},
+			wantHolder: "b",
+			wantErr:    true,
+			wantHeld:   []string{"b", "c", "a"},
+		},
+		{
+			name:     "write fails in a minority",
+			clusters: []quorumCluster{{failWrites: true}, {}, {}},
+			wantHeld: []string{"", "a", "a"},
+		},
+		{
+			name:     "write fails in a majority",
+			clusters: []quorumCluster{{failWrites: true}, {failWrites: true}, {}},
+			wantErr:  true,
+			wantHeld: []string{"", "", "a"},
+		},
+		{
+			name:       "ours in a majority",
+			clusters:   []quorumCluster{{holder: "a"}, {holder: "a"}, {holder: "b"}},
+			wantHolder: "a",
+			wantHeld:   []string{"a", "a", "b"},
+		},
+		{
+			name:     "five clusters",
+			clusters: []quorumCluster{{holder: "b"}, {holder: "b"}, {}, {}, {}},
+			wantHeld: []string{"b", "b", "a", "a", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, stores := newQuorumTestLock(t, tt.clusters)
+			ctx := context.Background()
+
+			if _, _, err := l.Get(ctx); err != nil {
+				t.Fatal(err)
+			}
+			// Expiry is told from when the record was observed.
+			for i, c := range tt.clusters {
+				if c.expired {
+					l.members[i].observed = time.Now().Add(-time.Minute)
+				}
+			}
+			record, _, err := l.Get(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if record.HolderIdentity != tt.wantHolder {
+				t.Errorf("Get returned holder %q, want %q", record.HolderIdentity, tt.wantHolder)
+			}
+
+			err = l.Update(ctx, quorumRecord("a", time.Now()))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Update returned error %v, want error %v", err, tt.wantErr)
+			}
+			if l.lost.Load() != tt.wantErr {
+				t.Errorf("lost is %v, want %v", l.lost.Load(), tt.wantErr)
+			}
+			for i, store := range stores {
+				got, _ := store.Record("demo", "kle")
+				if got.HolderIdentity != tt.wantHeld[i] {
+					t.Errorf("cluster %d is held by %q, want %q", i, got.HolderIdentity, tt.wantHeld[i])
+				}
+			}
+		})
+	}
+}
+
+func TestQuorumLockRelease(t *testing.T) {
+	l, stores := newQuorumTestLock(t, []quorumCluster{{}, {}, {holder: "b"}})
+	ctx := context.Background()
+	if _, _, err := l.Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Update(ctx, quorumRecord("a", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another candidate takes the third cluster back meanwhile.
+	b := NewMemoryBackend(stores[2], "demo", "kle", resourcelock.ResourceLockConfig{Identity: "b"})
+	if _, _, err := b.Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Update(ctx, quorumRecord("b", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := l.Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Update(ctx, resourcelock.LeaderElectionRecord{LeaseDurationSeconds: 1}); err != nil {
+		t.Fatalf("release returned %v", err)
+	}
+	for i, want := range []string{"", "", "b"} {
+		got, _ := stores[i].Record("demo", "kle")
+		if got.HolderIdentity != want {
+			t.Errorf("cluster %d is held by %q after the release, want %q", i, got.HolderIdentity, want)
+		}
+	}
+}
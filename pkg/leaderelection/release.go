@@ -0,0 +1,85 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Release lets an operator move leadership off a candidate without stopping
+// it. Its zero value is ready to use.
+type Release struct {
+	mu sync.Mutex
+	// until is when the cool-down of the last request ends.
+	until time.Time
+	// requests counts the requests, so that a leader notices a request even
+	// if its cool-down ended before the leader looked.
+	requests atomic.Uint64
+}
+
+// Request makes every term of the candidate release its lease and keeps
+// the candidate out of the race for coolDown.
+func (r *Release) Request(coolDown time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.until = time.Now().Add(coolDown)
+	r.requests.Add(1)
+	klog.Infof("Release of leadership requested, cooling down for %v", coolDown)
+}
+
+// CoolingDown reports whether the candidate is kept out of the race, and
+// for how much longer.
+func (r *Release) CoolingDown() (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	left := time.Until(r.until)
+	return left, left > 0
+}
+
+// attach gates and yields c on the requests.
+func (r *Release) attach(c *candidate) {
+	var seen atomic.Uint64
+	seen.Store(r.requests.Load())
+
+	c.gates = append(c.gates, func() bool {
+		// Requests made while not leading are served by the cool-down.
+		seen.Store(r.requests.Load())
+		_, cooling := r.CoolingDown()
+		return !cooling
+	})
+	c.yields = append(c.yields, func() bool {
+		requests := r.requests.Load()
+		if seen.Swap(requests) == requests {
+			return false
+		}
+		klog.Infof("Releasing lease %s on request", c.lec.Name)
+		c.metrics.stepDown(c.lec.Name, "release")
+		return true
+	})
+}
@@ -0,0 +1,112 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+// TestRelease asks the leader to release its lease, and checks that it
+// stops leading and leaves the lease to the other candidate while it cools
+// down.
+func TestRelease(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs leader elections")
+	}
+	config := &componentbaseconfig.LeaderElectionConfiguration{
+		LeaderElect:       true,
+		LeaseDuration:     metav1.Duration{Duration: 2 * time.Second},
+		RenewDeadline:     metav1.Duration{Duration: 1500 * time.Millisecond},
+		RetryPeriod:       metav1.Duration{Duration: 100 * time.Millisecond},
+		ResourceLock:      MemoryResourceLock,
+		ResourceName:      "release",
+		ResourceNamespace: "test",
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	releases := map[string]*Release{}
+	terms := map[string]chan context.Context{}
+	start := func(identity string) {
+		release, started := &Release{}, make(chan context.Context, 10)
+		releases[identity], terms[identity] = release, started
+		opts := DefaultOptions()
+		opts.Identity = identity
+		opts.Release = release
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run := func(ctx context.Context) {
+				started <- ctx
+				<-ctx.Done()
+			}
+			if err := NewLeaderElection(run, nil, config, opts, ctx); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	leads := func(identity string) context.Context {
+		t.Helper()
+		select {
+		case leading := <-terms[identity]:
+			return leading
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s did not lead", identity)
+			return nil
+		}
+	}
+
+	start("release-a")
+	leading := leads("release-a")
+	start("release-b")
+
+	requested := time.Now()
+	releases["release-a"].Request(time.Minute)
+	select {
+	case <-leading.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("release-a kept leading once asked to release")
+	}
+	// Released rather than left to expire, so that release-b takes over
+	// before the lease duration.
+	leads("release-b")
+	if took := time.Since(requested); took >= config.LeaseDuration.Duration {
+		t.Errorf("release-b took over after %v, the lease was not released", took)
+	}
+	if record, ok := DefaultMemoryStore().Record("test", "release"); !ok || record.HolderIdentity != "release-b" {
+		t.Errorf("holder = %q, want release-b", record.HolderIdentity)
+	}
+	if len(terms["release-a"]) > 0 {
+		t.Error("release-a led again while cooling down")
+	}
+}
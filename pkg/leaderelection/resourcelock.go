@@ -0,0 +1,419 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// Resource lock types accepted by --leader-elect-resource-lock.
+//
+// client-go only ships the Lease lock nowadays. The ConfigMap and Endpoints
+// locks, and the MultiLocks used to migrate off them, are kept here so that
+// kle can coordinate with components which still use them. The upgrade path
+// is configmaps -> configmapsleases -> leases (respectively endpoints ->
+// endpointsleases -> leases), rolling every candidate to the next step
+// before moving on to the one after.
+const (
+	LeasesResourceLock           = resourcelock.LeasesResourceLock
+	ConfigMapsResourceLock       = "configmaps"
+	EndpointsResourceLock        = "endpoints"
+	ConfigMapsLeasesResourceLock = "configmapsleases"
+	EndpointsLeasesResourceLock  = "endpointsleases"
+)
+
+var (
+	leaseRule = rbacv1.PolicyRule{
+		APIGroups: []string{"coordination.k8s.io"},
+		Resources: []string{"leases"},
+		Verbs:     []string{"get", "create", "update"},
+	}
+	configMapRule = rbacv1.PolicyRule{
+		APIGroups: []string{""},
+		Resources: []string{"configmaps"},
+		Verbs:     []string{"get", "create", "update"},
+	}
+	endpointsRule = rbacv1.PolicyRule{
+		APIGroups: []string{""},
+		Resources: []string{"endpoints"},
+		Verbs:     []string{"get", "create", "update"},
+	}
+	// memberLeaseRule covers the heartbeat Leases of LeaseMembership.
+	memberLeaseRule = rbacv1.PolicyRule{
+		APIGroups: []string{"coordination.k8s.io"},
+		Resources: []string{"leases"},
+		Verbs:     []string{"get", "list", "create", "update", "delete"},
+	}
+)
+
+// describeRules formats rules the way they are logged at startup, e.g.
+// "coordination.k8s.io/leases: get, create, update".
+func describeRules(rules []rbacv1.PolicyRule) string {
+	described := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		resource := strings.Join(rule.Resources, ",")
+		if group := strings.Join(rule.APIGroups, ","); len(group) > 0 {
+			resource = group + "/" + resource
+		}
+		described = append(described, fmt.Sprintf("%s: %s", resource, strings.Join(rule.Verbs, ", ")))
+	}
+	return strings.Join(described, "; ")
+}
+
+// kubernetesBackend returns a BackendFunc creating the Kubernetes lock of
+// the given type.
+func kubernetesBackend(lockType string) BackendFunc {
+	return func(namespace, name string, client clientset.Interface, rlc resourcelock.ResourceLockConfig) (Backend, error) {
+		if len(namespace) == 0 {
+			return nil, fmt.Errorf("namespace may not be empty")
+		}
+		if client == nil {
+			return nil, fmt.Errorf("resource lock %q needs a kubernetes client", lockType)
+		}
+
+		meta := metav1.ObjectMeta{Namespace: namespace, Name: name}
+		leaseLock := &LeaseLock{
+			LeaseMeta:  meta,
+			Client:     client.CoordinationV1(),
+			LockConfig: rlc,
+		}
+		configMapLock := &ConfigMapLock{
+			ConfigMapMeta: meta,
+			Client:        client.CoreV1(),
+			LockConfig:    rlc,
+		}
+		endpointsLock := &EndpointsLock{
+			EndpointsMeta: meta,
+			Client:        client.CoreV1(),
+			LockConfig:    rlc,
+		}
+
+		switch lockType {
+		case LeasesResourceLock:
+			return leaseLock, nil
+		case ConfigMapsResourceLock:
+			return configMapLock, nil
+		case EndpointsResourceLock:
+			return endpointsLock, nil
+		case ConfigMapsLeasesResourceLock:
+			return &resourcelock.MultiLock{Primary: configMapLock, Secondary: leaseLock}, nil
+		case EndpointsLeasesResourceLock:
+			return &resourcelock.MultiLock{Primary: endpointsLock, Secondary: leaseLock}, nil
+		default:
+			return nil, fmt.Errorf("invalid kubernetes resource lock %q", lockType)
+		}
+	}
+}
+
+// decodeRecord reads the leader election record stored in annotations.
+func decodeRecord(annotations map[string]string) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	var record resourcelock.LeaderElectionRecord
+	recordBytes := []byte(annotations[resourcelock.LeaderElectionRecordAnnotationKey])
+	if len(recordBytes) > 0 {
+		if err := json.Unmarshal(recordBytes, &record); err != nil {
+			return nil, nil, err
+		}
+	}
+	return &record, recordBytes, nil
+}
+
+// encodeRecord stores ler in annotations.
+func encodeRecord(annotations map[string]string, ler resourcelock.LeaderElectionRecord) (map[string]string, error) {
+	recordBytes, err := json.Marshal(ler)
+	if err != nil {
+		return nil, err
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[resourcelock.LeaderElectionRecordAnnotationKey] = string(recordBytes)
+	return annotations, nil
+}
+
+// LeaseLock stores the leader election record in the spec of a Lease, like
+// the lock of client-go, and publishes the leader's metadata in its
+// annotations.
+type LeaseLock struct {
+	// LeaseMeta should contain a Name and a Namespace of a
+	// LeaseMeta object that the LeaderElector will attempt to lead.
+	LeaseMeta         metav1.ObjectMeta
+	Client            coordinationv1client.LeasesGetter
+	LockConfig        resourcelock.ResourceLockConfig
+	lease             *coordinationv1.Lease
+	leaderAnnotations map[string]string
+}
+
+// Get returns the election record from a Lease spec.
+func (ll *LeaseLock) Get(ctx context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	lease, err := ll.Client.Leases(ll.LeaseMeta.Namespace).Get(ctx, ll.LeaseMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	ll.lease = lease
+	record := resourcelock.LeaseSpecToLeaderElectionRecord(&ll.lease.Spec)
+	recordBytes, err := json.Marshal(*record)
+	if err != nil {
+		return nil, nil, err
+	}
+	return record, recordBytes, nil
+}
+
+// Create attempts to create a Lease.
+func (ll *LeaseLock) Create(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	var err error
+	ll.lease, err = ll.Client.Leases(ll.LeaseMeta.Namespace).Create(ctx, &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ll.LeaseMeta.Name,
+			Namespace:   ll.LeaseMeta.Namespace,
+			Annotations: applyLeaderAnnotations(nil, ll.leaderAnnotations),
+		},
+		Spec: resourcelock.LeaderElectionRecordToLeaseSpec(&ler),
+	}, metav1.CreateOptions{})
+	return err
+}
+
+// Update will update an existing Lease spec.
+func (ll *LeaseLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	if ll.lease == nil {
+		return errors.New("lease not initialized, call get or create first")
+	}
+	ll.lease.Annotations = applyLeaderAnnotations(ll.lease.Annotations, ll.leaderAnnotations)
+	ll.lease.Spec = resourcelock.LeaderElectionRecordToLeaseSpec(&ler)
+	lease, err := ll.Client.Leases(ll.LeaseMeta.Namespace).Update(ctx, ll.lease, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	ll.lease = lease
+	return nil
+}
+
+// RecordEvent in leader election while adding meta-data
+func (ll *LeaseLock) RecordEvent(s string) {
+	if ll.LockConfig.EventRecorder == nil || ll.lease == nil {
+		return
+	}
+	events := fmt.Sprintf("%v %v", ll.LockConfig.Identity, s)
+	subject := &coordinationv1.Lease{ObjectMeta: ll.lease.ObjectMeta}
+	// Populate the type meta, so we don't have to get it from the schema
+	subject.Kind = "Lease"
+	subject.APIVersion = coordinationv1.SchemeGroupVersion.String()
+	ll.LockConfig.EventRecorder.Eventf(subject, corev1.EventTypeNormal, "LeaderElection", events)
+}
+
+// Describe is used to convert details on current resource lock
+// into a string
+func (ll *LeaseLock) Describe() string {
+	return fmt.Sprintf("%v/%v", ll.LeaseMeta.Namespace, ll.LeaseMeta.Name)
+}
+
+// Identity returns the Identity of the lock
+func (ll *LeaseLock) Identity() string {
+	return ll.LockConfig.Identity
+}
+
+func (ll *LeaseLock) setLeaderAnnotations(annotations map[string]string) {
+	ll.leaderAnnotations = annotations
+}
+
+// ConfigMapLock stores the leader election record in an annotation of a
+// ConfigMap.
+type ConfigMapLock struct {
+	// ConfigMapMeta should contain a Name and a Namespace of a
+	// ConfigMap object that the LeaderElector will attempt to lead.
+	ConfigMapMeta metav1.ObjectMeta
+	Client        corev1client.ConfigMapsGetter
+	LockConfig    resourcelock.ResourceLockConfig
+	cm            *corev1.ConfigMap
+
+	leaderAnnotations map[string]string
+}
+
+// Get returns the election record from a ConfigMap annotation.
+func (cml *ConfigMapLock) Get(ctx context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	cm, err := cml.Client.ConfigMaps(cml.ConfigMapMeta.Namespace).Get(ctx, cml.ConfigMapMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	cml.cm = cm
+	return decodeRecord(cm.Annotations)
+}
+
+// Create attempts to create a ConfigMap holding the election record.
+func (cml *ConfigMapLock) Create(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	annotations, err := encodeRecord(applyLeaderAnnotations(nil, cml.leaderAnnotations), ler)
+	if err != nil {
+		return err
+	}
+	cml.cm, err = cml.Client.ConfigMaps(cml.ConfigMapMeta.Namespace).Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cml.ConfigMapMeta.Name,
+			Namespace:   cml.ConfigMapMeta.Namespace,
+			Annotations: annotations,
+		},
+	}, metav1.CreateOptions{})
+	return err
+}
+
+// Update will update an existing election record annotation.
+func (cml *ConfigMapLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	if cml.cm == nil {
+		return errors.New("configmap not initialized, call get or create first")
+	}
+	annotations, err := encodeRecord(applyLeaderAnnotations(cml.cm.Annotations, cml.leaderAnnotations), ler)
+	if err != nil {
+		return err
+	}
+	cml.cm.Annotations = annotations
+	cm, err := cml.Client.ConfigMaps(cml.ConfigMapMeta.Namespace).Update(ctx, cml.cm, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	cml.cm = cm
+	return nil
+}
+
+// RecordEvent in leader election while adding meta-data
+func (cml *ConfigMapLock) RecordEvent(s string) {
+	if cml.LockConfig.EventRecorder == nil || cml.cm == nil {
+		return
+	}
+	events := fmt.Sprintf("%v %v", cml.LockConfig.Identity, s)
+	subject := &corev1.ConfigMap{ObjectMeta: cml.cm.ObjectMeta}
+	// Populate the type meta, so we don't have to get it from the schema
+	subject.Kind = "ConfigMap"
+	subject.APIVersion = corev1.SchemeGroupVersion.String()
+	cml.LockConfig.EventRecorder.Eventf(subject, corev1.EventTypeNormal, "LeaderElection", events)
+}
+
+// Describe is used to convert details on current resource lock
+// into a string
+func (cml *ConfigMapLock) Describe() string {
+	return fmt.Sprintf("%v/%v", cml.ConfigMapMeta.Namespace, cml.ConfigMapMeta.Name)
+}
+
+// Identity returns the Identity of the lock
+func (cml *ConfigMapLock) Identity() string {
+	return cml.LockConfig.Identity
+}
+
+func (cml *ConfigMapLock) setLeaderAnnotations(annotations map[string]string) {
+	cml.leaderAnnotations = annotations
+}
+
+// EndpointsLock stores the leader election record in an annotation of an
+// Endpoints object.
+type EndpointsLock struct {
+	// EndpointsMeta should contain a Name and a Namespace of an
+	// Endpoints object that the LeaderElector will attempt to lead.
+	EndpointsMeta metav1.ObjectMeta
+	Client        corev1client.EndpointsGetter
+	LockConfig    resourcelock.ResourceLockConfig
+	e             *corev1.Endpoints //nolint:staticcheck // Endpoints is what older components lock on.
+
+	leaderAnnotations map[string]string
+}
+
+// Get returns the election record from an Endpoints annotation.
+func (el *EndpointsLock) Get(ctx context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	ep, err := el.Client.Endpoints(el.EndpointsMeta.Namespace).Get(ctx, el.EndpointsMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	el.e = ep
+	return decodeRecord(ep.Annotations)
+}
+
+// Create attempts to create an Endpoints object holding the election record.
+func (el *EndpointsLock) Create(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	annotations, err := encodeRecord(applyLeaderAnnotations(nil, el.leaderAnnotations), ler)
+	if err != nil {
+		return err
+	}
+	el.e, err = el.Client.Endpoints(el.EndpointsMeta.Namespace).Create(ctx, &corev1.Endpoints{ //nolint:staticcheck
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        el.EndpointsMeta.Name,
+			Namespace:   el.EndpointsMeta.Namespace,
+			Annotations: annotations,
+		},
+	}, metav1.CreateOptions{})
+	return err
+}
+
+// Update will update an existing election record annotation.
+func (el *EndpointsLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	if el.e == nil {
+		return errors.New("endpoint not initialized, call get or create first")
+	}
+	annotations, err := encodeRecord(applyLeaderAnnotations(el.e.Annotations, el.leaderAnnotations), ler)
+	if err != nil {
+		return err
+	}
+	el.e.Annotations = annotations
+	ep, err := el.Client.Endpoints(el.EndpointsMeta.Namespace).Update(ctx, el.e, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	el.e = ep
+	return nil
+}
+
+// RecordEvent in leader election while adding meta-data
+func (el *EndpointsLock) RecordEvent(s string) {
+	if el.LockConfig.EventRecorder == nil || el.e == nil {
+		return
+	}
+	events := fmt.Sprintf("%v %v", el.LockConfig.Identity, s)
+	subject := &corev1.Endpoints{ObjectMeta: el.e.ObjectMeta} //nolint:staticcheck
+	// Populate the type meta, so we don't have to get it from the schema
+	subject.Kind = "Endpoints"
+	subject.APIVersion = corev1.SchemeGroupVersion.String()
+	el.LockConfig.EventRecorder.Eventf(subject, corev1.EventTypeNormal, "LeaderElection", events)
+}
+
+// Describe is used to convert details on current resource lock
+// into a string
+func (el *EndpointsLock) Describe() string {
+	return fmt.Sprintf("%v/%v", el.EndpointsMeta.Namespace, el.EndpointsMeta.Name)
+}
+
+// Identity returns the Identity of the lock
+func (el *EndpointsLock) Identity() string {
+	return el.LockConfig.Identity
+}
+
+func (el *EndpointsLock) setLeaderAnnotations(annotations map[string]string) {
+	el.leaderAnnotations = annotations
+}
@@ -0,0 +1,87 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"testing"
+
+	fakeclient "github.com/yshngg/kle/pkg/client/fake"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func TestKubernetesLocks(t *testing.T) {
+	tests := []struct {
+		name string
+		// lockTypes are the lock types of candidates a and b.
+		lockTypes [2]string
+		// wantHolder is the holder a sees once b took over, b if empty.
+		wantHolder string
+	}{
+		{name: "leases", lockTypes: [2]string{LeasesResourceLock, LeasesResourceLock}},
+		{name: "configmaps", lockTypes: [2]string{ConfigMapsResourceLock, ConfigMapsResourceLock}},
+		{name: "endpoints", lockTypes: [2]string{EndpointsResourceLock, EndpointsResourceLock}},
+		{name: "configmapsleases", lockTypes: [2]string{ConfigMapsLeasesResourceLock, ConfigMapsLeasesResourceLock}},
+		{name: "endpointsleases", lockTypes: [2]string{EndpointsLeasesResourceLock, EndpointsLeasesResourceLock}},
+		// Candidates one step apart on the upgrade path see each other.
+		{name: "configmaps to configmapsleases", lockTypes: [2]string{ConfigMapsResourceLock, ConfigMapsLeasesResourceLock}},
+		// A MultiLock whose objects disagree sees an unknown leader, and
+		// keeps away from both.
+		{name: "configmapsleases to leases", lockTypes: [2]string{ConfigMapsLeasesResourceLock, LeasesResourceLock}, wantHolder: resourcelock.UnknownLeader},
+		{name: "endpoints to endpointsleases", lockTypes: [2]string{EndpointsResourceLock, EndpointsLeasesResourceLock}},
+		{name: "endpointsleases to leases", lockTypes: [2]string{EndpointsLeasesResourceLock, LeasesResourceLock}, wantHolder: resourcelock.UnknownLeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := fakeclient.Kubernetes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			locks := map[string]resourcelock.Interface{}
+			for i, identity := range []string{"a", "b"} {
+				lock, err := NewBackend(tt.lockTypes[i], "demo", "kle", client, resourcelock.ResourceLockConfig{Identity: identity})
+				if err != nil {
+					t.Fatal(err)
+				}
+				locks[identity] = lock
+			}
+			wantHolder := tt.wantHolder
+			if wantHolder == "" {
+				wantHolder = "b"
+			}
+			runLockSteps(t, locks, []lockStep{
+				{lock: "a", op: "get", want: notFound},
+				{lock: "a", op: "create", holder: "a", want: succeeds},
+				{lock: "b", op: "get", holder: "a", want: succeeds},
+				{lock: "a", op: "update", holder: "a", want: succeeds},
+				{lock: "b", op: "update", holder: "b", want: conflicts},
+				{lock: "b", op: "get", holder: "a", want: succeeds},
+				{lock: "b", op: "update", holder: "b", want: succeeds},
+				{lock: "a", op: "get", holder: wantHolder, want: succeeds},
+			})
+		})
+	}
+
+	if _, err := NewBackend("nope", "demo", "kle", nil, resourcelock.ResourceLockConfig{}); err == nil {
+		t.Error("NewBackend() of an unknown lock type succeeded")
+	}
+}
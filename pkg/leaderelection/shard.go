@@ -0,0 +1,256 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	componentbaseconfig "k8s.io/component-base/config"
+	"k8s.io/klog/v2"
+)
+
+type shardKey struct{}
+
+type shardStatusKey struct{}
+
+// ShardFromContext returns the shard a leadership context was handed out
+// for, if leader election is sharded.
+func ShardFromContext(ctx context.Context) (int, bool) {
+	shard, ok := ctx.Value(shardKey{}).(int)
+	return shard, ok
+}
+
+// OwnedShardsFromContext returns the shards this candidate currently owns,
+// sorted, if leader election is sharded.
+func OwnedShardsFromContext(ctx context.Context) []int {
+	status, ok := ctx.Value(shardStatusKey{}).(*ShardStatus)
+	if !ok {
+		return nil
+	}
+	return status.Owned()
+}
+
+// ShardStatus tracks the shards of a sharded election as seen by this
+// candidate. Its zero value is ready to use, and it serves itself as JSON.
+type ShardStatus struct {
+	mu       sync.Mutex
+	election string
+	count    int
+	members  int
+	owned    map[int]bool
+	holders  map[int]string
+}
+
+// shardStatusResponse is what ShardStatus serves.
+type shardStatusResponse struct {
+	Election string             `json:"election"`
+	Members  int                `json:"members"`
+	Share    int                `json:"share"`
+	Owned    []int              `json:"owned"`
+	Shards   []shardStatusEntry `json:"shards"`
+}
+
+type shardStatusEntry struct {
+	Shard  int    `json:"shard"`
+	Lease  string `json:"lease"`
+	Holder string `json:"holder"`
+	Owned  bool   `json:"owned"`
+}
+
+// ServeHTTP writes the shard ownership as JSON.
+func (s *ShardStatus) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	resp := shardStatusResponse{
+		Election: s.election,
+		Members:  s.members,
+		Share:    s.shareLocked(),
+		Owned:    s.ownedLocked(),
+		Shards:   make([]shardStatusEntry, 0, s.count),
+	}
+	for i := range s.count {
+		resp.Shards = append(resp.Shards, shardStatusEntry{
+			Shard:  i,
+			Lease:  ShardName(s.election, i),
+			Holder: s.holders[i],
+			Owned:  s.owned[i],
+		})
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		klog.Errorf("failed to write response: %v", err)
+	}
+}
+
+// Owned returns the shards owned by this candidate, sorted.
+func (s *ShardStatus) Owned() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ownedLocked()
+}
+
+// Share returns the fair number of shards per member.
+func (s *ShardStatus) Share() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.shareLocked()
+}
+
+func (s *ShardStatus) reset(election string, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.election = election
+	s.count = count
+	s.members = 0
+	s.owned = map[int]bool{}
+	s.holders = map[int]string{}
+}
+
+func (s *ShardStatus) setMembers(members int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.members = members
+}
+
+func (s *ShardStatus) setOwned(shard int, owned bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if owned {
+		s.owned[shard] = true
+		return
+	}
+	delete(s.owned, shard)
+}
+
+func (s *ShardStatus) setHolder(shard int, holder string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.holders[shard] = holder
+}
+
+func (s *ShardStatus) ownedLocked() []int {
+	owned := make([]int, 0, len(s.owned))
+	for shard := range s.owned {
+		owned = append(owned, shard)
+	}
+	sort.Ints(owned)
+	return owned
+}
+
+// shareLocked is the number of shards each member should own, rounded up so
+// that every shard has an owner. Until the members are known this
+// candidate assumes it is alone.
+func (s *ShardStatus) shareLocked() int {
+	members := max(s.members, 1)
+	return (s.count + members - 1) / members
+}
+
+// ShardName returns the name of the lease of shard in a sharded election.
+func ShardName(election string, shard int) string {
+	return election + "-" + strconv.Itoa(shard)
+}
+
+// runShards campaigns for every shard lease, keeping at most a fair share of
+// them. A candidate holding more than its share, typically because members
+// joined, releases its highest shards one at a time, and one holding its
+// share stops campaigning for the others.
+func runShards(
+	ctx context.Context,
+	config *componentbaseconfig.LeaderElectionConfiguration,
+	opts *Options,
+	members *memberTracker,
+	status *ShardStatus,
+	newCandidate func(name string) (*candidate, error),
+) error {
+	status.reset(config.ResourceName, opts.Shards)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	share := func() int {
+		status.setMembers(len(members.Members()))
+		return status.Share()
+	}
+
+	candidates := make([]*candidate, opts.Shards)
+	for i := range opts.Shards {
+		name := ShardName(config.ResourceName, i)
+		c, err := newCandidate(name)
+		if err != nil {
+			return err
+		}
+
+		run := c.run
+		c.run = func(ctx context.Context) {
+			status.setOwned(i, true)
+			opts.Metrics.shardOwned(config.ResourceName, i, true)
+			defer func() {
+				status.setOwned(i, false)
+				opts.Metrics.shardOwned(config.ResourceName, i, false)
+			}()
+			ctx = context.WithValue(ctx, shardKey{}, i)
+			ctx = context.WithValue(ctx, shardStatusKey{}, status)
+			run(ctx)
+		}
+		c.gates = append(c.gates, func() bool {
+			return len(status.Owned()) < share()
+		})
+		c.yields = append(c.yields, func() bool {
+			owned := status.Owned()
+			return len(owned) > share() && owned[len(owned)-1] == i
+		})
+		c.onNewLeader = append(c.onNewLeader, func(identity string) {
+			status.setHolder(i, identity)
+		})
+		opts.Metrics.shardOwned(config.ResourceName, i, false)
+		candidates[i] = c
+	}
+
+	errs := make(chan error, len(candidates))
+	for _, c := range candidates {
+		go func() {
+			errs <- c.loop(ctx)
+		}()
+	}
+
+	// A shard loop only returns early when its loss policy says to stop,
+	// which stops every other shard too.
+	var err error
+	for range candidates {
+		if shardErr := <-errs; shardErr != nil && err == nil {
+			err = shardErr
+		}
+		cancel()
+	}
+	if err != nil {
+		return fmt.Errorf("shard of %s, err: %w", config.ResourceName, err)
+	}
+	return nil
+}
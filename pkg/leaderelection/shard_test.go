@@ -0,0 +1,175 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+func TestShardStatusShare(t *testing.T) {
+	tests := []struct {
+		name    string
+		count   int
+		members int
+		want    int
+	}{
+		{name: "members unknown", count: 4, want: 4},
+		{name: "alone", count: 4, members: 1, want: 4},
+		{name: "even", count: 4, members: 2, want: 2},
+		{name: "uneven", count: 5, members: 2, want: 3},
+		{name: "more members than shards", count: 2, members: 5, want: 1},
+		{name: "one shard", count: 1, members: 3, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ShardStatus{}
+			s.reset("kle", tt.count)
+			s.setMembers(tt.members)
+			if got := s.Share(); got != tt.want {
+				t.Errorf("Share() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShardStatusOwned(t *testing.T) {
+	s := &ShardStatus{}
+	s.reset("kle", 4)
+	for _, shard := range []int{3, 0, 2} {
+		s.setOwned(shard, true)
+	}
+	s.setOwned(2, false)
+	if got, want := fmt.Sprint(s.Owned()), "[0 3]"; got != want {
+		t.Errorf("Owned() = %s, want %s", got, want)
+	}
+}
+
+// TestShards runs candidates on the memory lock until the shards are
+// spread across them.
+func TestShards(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs leader elections")
+	}
+	tests := []struct {
+		shards     int
+		candidates int
+		// joining starts the other candidates once the first one owns
+		// every shard, so that it has to give shards up.
+		joining bool
+	}{
+		{shards: 4, candidates: 2},
+		{shards: 3, candidates: 2},
+		{shards: 2, candidates: 3},
+		{shards: 4, candidates: 2, joining: true},
+		{shards: 5, candidates: 3, joining: true},
+	}
+	for _, tt := range tests {
+		name := fmt.Sprintf("%d shards %d candidates", tt.shards, tt.candidates)
+		if tt.joining {
+			name += " joining"
+		}
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			var wg sync.WaitGroup
+			defer func() {
+				cancel()
+				wg.Wait()
+			}()
+
+			config := &componentbaseconfig.LeaderElectionConfiguration{
+				LeaderElect:       true,
+				LeaseDuration:     metav1.Duration{Duration: 2 * time.Second},
+				RenewDeadline:     metav1.Duration{Duration: 1500 * time.Millisecond},
+				RetryPeriod:       metav1.Duration{Duration: 100 * time.Millisecond},
+				ResourceLock:      MemoryResourceLock,
+				ResourceName:      fmt.Sprintf("shards-%d-%d-%v", tt.shards, tt.candidates, tt.joining),
+				ResourceNamespace: "test",
+			}
+			statuses := make([]*ShardStatus, tt.candidates)
+			for i := range statuses {
+				statuses[i] = &ShardStatus{}
+				opts := DefaultOptions()
+				opts.Identity = fmt.Sprintf("candidate-%d", i)
+				opts.Shards = tt.shards
+				opts.ShardStatus = statuses[i]
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					run := func(ctx context.Context) { <-ctx.Done() }
+					if err := NewLeaderElection(run, nil, config, opts, ctx); err != nil {
+						t.Error(err)
+					}
+				}()
+				if i == 0 && tt.joining {
+					waitFor(t, 5*time.Second, func() bool { return len(statuses[0].Owned()) == tt.shards })
+				}
+			}
+
+			share := (tt.shards + tt.candidates - 1) / tt.candidates
+			deadline := time.Now().Add(20 * time.Second)
+			for {
+				owners := map[int]int{}
+				spread := true
+				for _, s := range statuses {
+					owned := s.Owned()
+					spread = spread && len(owned) <= share
+					for _, shard := range owned {
+						owners[shard]++
+					}
+				}
+				for shard := range tt.shards {
+					spread = spread && owners[shard] == 1
+				}
+				if spread {
+					return
+				}
+				if time.Now().After(deadline) {
+					for i, s := range statuses {
+						t.Logf("candidate-%d owns %v", i, s.Owned())
+					}
+					t.Fatalf("shards were not spread with at most %d per candidate", share)
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+		})
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after timeout.
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %v", timeout)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
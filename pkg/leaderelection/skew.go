@@ -0,0 +1,172 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+)
+
+// The sources of clock skew observations.
+const (
+	ClockSkewSourceAPIServer = "apiserver"
+	ClockSkewSourceLease     = "lease"
+)
+
+// ClockSkew estimates how far the local clock is off. Leader election
+// trusts the clocks of the candidates: a holder renewing with a clock
+// running behind looks expired to the others before its lease is up.
+//
+// The API server's Date response header, which has a resolution of a
+// second, is the reference. Renew times of leases held by other candidates
+// are observed as well, as a lower bound of the offset of the holder's
+// clock, but a large one may be the holder's fault rather than ours, so
+// only the API server keeps a candidate out of the race. Its zero value is
+// ready to use and never keeps a candidate out.
+type ClockSkew struct {
+	// Max is the skew from the API server beyond which the candidate does
+	// not campaign and the healthz check fails. 0 disables both.
+	Max time.Duration
+	// Metrics, when set, records the skew.
+	Metrics *Metrics
+
+	mu    sync.Mutex
+	skews map[string]time.Duration
+}
+
+// ObserveDate records the Date header of an API server response received
+// at local time.
+func (s *ClockSkew) ObserveDate(server, local time.Time) {
+	// Date is truncated to the second, take the middle of that second.
+	s.observe(ClockSkewSourceAPIServer, server.Add(500*time.Millisecond).Sub(local))
+}
+
+// Skew returns the last skew observed from source.
+func (s *ClockSkew) Skew(source string) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	skew, ok := s.skews[source]
+	return skew, ok
+}
+
+// Name implements healthz.HealthChecker.
+func (s *ClockSkew) Name() string {
+	return "clock-skew"
+}
+
+// Check implements healthz.HealthChecker. It fails while the skew from the
+// API server is beyond Max.
+func (s *ClockSkew) Check(_ *http.Request) error {
+	if skew, ok := s.tooLarge(); ok {
+		return fmt.Errorf("clock is %v off the API server, more than %v", skew, s.Max)
+	}
+	return nil
+}
+
+// mayCampaign keeps the candidate out of the race while the skew from the
+// API server is beyond Max.
+func (s *ClockSkew) mayCampaign() bool {
+	skew, ok := s.tooLarge()
+	if ok {
+		klog.V(2).Infof("Not campaigning, clock is %v off the API server, more than %v", skew, s.Max)
+	}
+	return !ok
+}
+
+func (s *ClockSkew) tooLarge() (time.Duration, bool) {
+	if s.Max <= 0 {
+		return 0, false
+	}
+	skew, ok := s.Skew(ClockSkewSourceAPIServer)
+	return skew, ok && skew.Abs() > s.Max
+}
+
+func (s *ClockSkew) observe(source string, skew time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.skews == nil {
+		s.skews = map[string]time.Duration{}
+	}
+	s.skews[source] = skew
+	s.Metrics.clockSkewObserved(source, skew)
+}
+
+// watch wraps lock to observe the renew times of the other holders of the
+// lease. retryPeriod is how often the lease is read, and at most how often
+// a holder renews it.
+func (s *ClockSkew) watch(lock resourcelock.Interface, retryPeriod time.Duration) resourcelock.Interface {
+	if s == nil {
+		return lock
+	}
+	return &skewLock{Interface: lock, skew: s, retryPeriod: retryPeriod}
+}
+
+type skewLock struct {
+	resourcelock.Interface
+	skew        *ClockSkew
+	retryPeriod time.Duration
+
+	mu      sync.Mutex
+	renewed time.Time
+}
+
+func (l *skewLock) Get(ctx context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	ler, raw, err := l.Interface.Get(ctx)
+	if err != nil || ler == nil || ler.HolderIdentity == "" || ler.HolderIdentity == l.Identity() {
+		return ler, raw, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if ler.RenewTime.Time.Equal(l.renewed) {
+		return ler, raw, err
+	}
+	first := l.renewed.IsZero()
+	l.renewed = ler.RenewTime.Time
+	if first {
+		// Nothing tells how long ago that renewal was.
+		return ler, raw, err
+	}
+
+	// A renewal that was not there at the previous read happened since,
+	// within a jittered retry period, if the clocks agree. Anything outside
+	// of that is skew.
+	window := time.Duration(float64(l.retryPeriod) * (1 + leaderelection.JitterFactor))
+	offset := ler.RenewTime.Sub(time.Now())
+	switch {
+	case offset > 0:
+	case offset < -window:
+		offset += window
+	default:
+		offset = 0
+	}
+	l.skew.observe(ClockSkewSourceLease, offset)
+	return ler, raw, err
+}
@@ -0,0 +1,117 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func TestClockSkewCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		max    time.Duration
+		offset time.Duration
+		// wantOK is whether the check passes and the candidate campaigns.
+		wantOK bool
+	}{
+		{name: "in sync", max: 5 * time.Second, wantOK: true},
+		{name: "within max", max: 5 * time.Second, offset: 3 * time.Second, wantOK: true},
+		{name: "ahead", max: 5 * time.Second, offset: 10 * time.Second},
+		{name: "behind", max: 5 * time.Second, offset: -10 * time.Second},
+		{name: "disabled", offset: time.Hour, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ClockSkew{Max: tt.max}
+			now := time.Now()
+			s.ObserveDate(now.Add(tt.offset).Truncate(time.Second), now)
+
+			if err := s.Check(nil); (err == nil) != tt.wantOK {
+				t.Errorf("Check() = %v, want passing %v", err, tt.wantOK)
+			}
+			if got := s.mayCampaign(); got != tt.wantOK {
+				t.Errorf("mayCampaign() = %v, want %v", got, tt.wantOK)
+			}
+		})
+	}
+
+	if err := (&ClockSkew{Max: time.Second}).Check(nil); err != nil {
+		t.Errorf("Check() without observations = %v, want passing", err)
+	}
+}
+
+// TestSkewLock reads the lease renewed by another holder, and checks the
+// skew observed from its renew times.
+func TestSkewLock(t *testing.T) {
+	const retryPeriod = time.Second
+	tests := []struct {
+		name string
+		// renewedIn is when the holder renews, from the time of the read.
+		renewedIn time.Duration
+		wantMin   time.Duration
+		wantMax   time.Duration
+	}{
+		{name: "in sync", renewedIn: -retryPeriod / 2},
+		{name: "ahead", renewedIn: 10 * time.Second, wantMin: 9 * time.Second, wantMax: 10 * time.Second},
+		// Less the jittered retry period the renewal may have happened in.
+		{name: "behind", renewedIn: -10 * time.Second, wantMin: -8 * time.Second, wantMax: -7 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			holder := NewMemoryBackend(store, "test", "skew", resourcelock.ResourceLockConfig{Identity: "b"})
+			s := &ClockSkew{}
+			lock := s.watch(NewMemoryBackend(store, "test", "skew", resourcelock.ResourceLockConfig{Identity: "a"}), retryPeriod)
+
+			ctx := context.Background()
+			renew := func(at time.Time) {
+				t.Helper()
+				record := resourcelock.LeaderElectionRecord{HolderIdentity: "b", RenewTime: metav1.NewTime(at)}
+				err := holder.Update(ctx, record)
+				if err != nil {
+					err = holder.Create(ctx, record)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, _, err := lock.Get(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+			renew(time.Now())
+			if _, ok := s.Skew(ClockSkewSourceLease); ok {
+				t.Fatal("skew observed from the first renewal read")
+			}
+			renew(time.Now().Add(tt.renewedIn))
+			skew, ok := s.Skew(ClockSkewSourceLease)
+			if !ok || skew < tt.wantMin || skew > tt.wantMax {
+				t.Errorf("skew = %v (%v), want between %v and %v", skew, ok, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// LeaseState sums up a LeaseStatus.
+type LeaseState string
+
+const (
+	// LeaseStateHeld is a lease renewed within its duration.
+	LeaseStateHeld LeaseState = "held"
+	// LeaseStateExpired is a lease its holder has not renewed within its
+	// duration, any candidate may acquire it.
+	LeaseStateExpired LeaseState = "expired"
+	// LeaseStateReleased is a lease given up by its last holder.
+	LeaseStateReleased LeaseState = "released"
+	// LeaseStateMissing is a lease that does not exist yet.
+	LeaseStateMissing LeaseState = "missing"
+)
+
+// LeaseStatus describes a lease as read from its lock.
+type LeaseStatus struct {
+	Lease         string          `json:"lease"`
+	State         LeaseState      `json:"state"`
+	Holder        string          `json:"holder,omitempty"`
+	AcquireTime   metav1.Time     `json:"acquireTime,omitempty"`
+	RenewTime     metav1.Time     `json:"renewTime,omitempty"`
+	LeaseDuration metav1.Duration `json:"leaseDuration"`
+	// SinceRenew is how long ago the lease was last renewed.
+	SinceRenew  metav1.Duration `json:"sinceRenew"`
+	Transitions int             `json:"transitions"`
+	Expired     bool            `json:"expired"`
+}
+
+// ReadLeaseStatus reads the status of the lease behind lock as of now.
+func ReadLeaseStatus(ctx context.Context, lock resourcelock.Interface, now time.Time) (LeaseStatus, error) {
+	status := LeaseStatus{Lease: lock.Describe()}
+	record, _, err := lock.Get(ctx)
+	if apierrors.IsNotFound(err) {
+		status.State = LeaseStateMissing
+		return status, nil
+	}
+	if err != nil {
+		return status, err
+	}
+
+	status.Holder = record.HolderIdentity
+	status.AcquireTime = record.AcquireTime
+	status.RenewTime = record.RenewTime
+	status.LeaseDuration = metav1.Duration{Duration: time.Duration(record.LeaseDurationSeconds) * time.Second}
+	status.SinceRenew = metav1.Duration{Duration: now.Sub(record.RenewTime.Time).Truncate(time.Millisecond)}
+	status.Transitions = record.LeaderTransitions
+	status.Expired = status.SinceRenew.Duration > status.LeaseDuration.Duration
+
+	switch {
+	case status.Holder == "":
+		status.State = LeaseStateReleased
+	case status.Expired:
+		status.State = LeaseStateExpired
+	default:
+		status.State = LeaseStateHeld
+	}
+	return status, nil
+}
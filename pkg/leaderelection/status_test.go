@@ -0,0 +1,100 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func TestReadLeaseStatus(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	acquired := metav1.NewTime(now.Add(-time.Minute))
+	tests := []struct {
+		name string
+		// record is written to the lease unless nil.
+		record *resourcelock.LeaderElectionRecord
+		want   LeaseStatus
+	}{
+		{name: "missing", want: LeaseStatus{State: LeaseStateMissing}},
+		{
+			name: "held",
+			record: &resourcelock.LeaderElectionRecord{
+				HolderIdentity: "a", LeaseDurationSeconds: 15, AcquireTime: acquired,
+				RenewTime: metav1.NewTime(now.Add(-5 * time.Second)), LeaderTransitions: 2,
+			},
+			want: LeaseStatus{
+				State: LeaseStateHeld, Holder: "a", AcquireTime: acquired, RenewTime: metav1.NewTime(now.Add(-5 * time.Second)),
+				LeaseDuration: metav1.Duration{Duration: 15 * time.Second}, SinceRenew: metav1.Duration{Duration: 5 * time.Second},
+				Transitions: 2,
+			},
+		},
+		{
+			name: "expired",
+			record: &resourcelock.LeaderElectionRecord{
+				HolderIdentity: "a", LeaseDurationSeconds: 15, AcquireTime: acquired,
+				RenewTime: metav1.NewTime(now.Add(-20 * time.Second)),
+			},
+			want: LeaseStatus{
+				State: LeaseStateExpired, Holder: "a", AcquireTime: acquired, RenewTime: metav1.NewTime(now.Add(-20 * time.Second)),
+				LeaseDuration: metav1.Duration{Duration: 15 * time.Second}, SinceRenew: metav1.Duration{Duration: 20 * time.Second},
+				Expired: true,
+			},
+		},
+		{
+			name: "released",
+			record: &resourcelock.LeaderElectionRecord{
+				LeaseDurationSeconds: 1, RenewTime: metav1.NewTime(now.Add(-time.Second)), LeaderTransitions: 3,
+			},
+			want: LeaseStatus{
+				State: LeaseStateReleased, RenewTime: metav1.NewTime(now.Add(-time.Second)),
+				LeaseDuration: metav1.Duration{Duration: time.Second}, SinceRenew: metav1.Duration{Duration: time.Second},
+				Transitions: 3,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			// Only reads, like the status command.
+			lock := NewMemoryBackend(NewMemoryStore(), "demo", "kle", resourcelock.ResourceLockConfig{})
+			if tt.record != nil {
+				if err := lock.Create(ctx, *tt.record); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := ReadLeaseStatus(ctx, lock, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.want.Lease = lock.Describe()
+			if got != tt.want {
+				t.Errorf("ReadLeaseStatus() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"sync"
+
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/klog/v2"
+)
+
+// VersionAnnotation publishes the binary version of a candidate on its
+// heartbeat.
+const VersionAnnotation = "kle.io/version"
+
+// Upgrade decisions, as counted by the metrics.
+const (
+	upgradeDecisionDefer  = "defer"
+	upgradeDecisionResume = "resume"
+	upgradeDecisionYield  = "yield"
+)
+
+// upgrade makes candidates defer to healthy candidates running a newer
+// version, and a leader running an older version step down for them, so
+// that leadership moves forward only during a rolling update.
+type upgrade struct {
+	self     rank
+	identity string
+	members  *memberTracker
+	metrics  *Metrics
+	election string
+
+	mu sync.Mutex
+	// deferringTo is the candidate this one last deferred to, empty if it
+	// campaigns.
+	deferringTo string
+}
+
+// newer returns a live candidate running a version newer than ours that
+// may campaign, and its version, if any.
+func (u *upgrade) newer() (Member, *utilversion.Version, bool) {
+	m, r, ok := outranking(u.members, u.identity, u.self, func(r rank) bool {
+		return r.version != nil && r.version.GreaterThan(u.self.version)
+	})
+	return m, r.version, ok
+}
+
+// mayCampaign keeps the candidate out of the race while a candidate
+// running a newer version is alive and may campaign, whether it holds the
+// lease or is about to take it.
+func (u *upgrade) mayCampaign() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	m, v, ok := u.newer()
+	switch {
+	case ok && u.deferringTo != m.Identity:
+		klog.Infof("Deferring %s to candidate %s running %s, ours is %s", u.election, m.Identity, v, u.self.version)
+		u.metrics.upgradeDecision(u.election, upgradeDecisionDefer)
+		u.deferringTo = m.Identity
+	case !ok && u.deferringTo != "":
+		klog.Infof("No candidate runs a version newer than %s anymore, campaigning for %s", u.self.version, u.election)
+		u.metrics.upgradeDecision(u.election, upgradeDecisionResume)
+		u.deferringTo = ""
+	}
+	return !ok
+}
+
+// shouldYield asks a leader running an older version to step down as soon
+// as a candidate running a newer version is alive.
+func (u *upgrade) shouldYield() bool {
+	m, v, ok := u.newer()
+	if !ok {
+		return false
+	}
+	klog.Infof("Stepping down from %s in favour of candidate %s running %s, ours is %s", u.election, m.Identity, v, u.self.version)
+	u.metrics.upgradeDecision(u.election, upgradeDecisionYield)
+	u.metrics.stepDown(u.election, "upgrade")
+	return true
+}
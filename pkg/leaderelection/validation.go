@@ -0,0 +1,162 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"net"
+	"slices"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/tools/leaderelection"
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+// ValidateLeaderElectionConfiguration validates config the way leader
+// election uses it. Nothing is checked unless config.LeaderElect is set.
+func ValidateLeaderElectionConfiguration(config *componentbaseconfig.LeaderElectionConfiguration, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if !config.LeaderElect {
+		return allErrs
+	}
+
+	leaseDuration := config.LeaseDuration.Duration
+	renewDeadline := config.RenewDeadline.Duration
+	retryPeriod := config.RetryPeriod.Duration
+	// client-go counts in whole seconds when writing the lease.
+	if leaseDuration < time.Second {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("leaseDuration"), leaseDuration.String(), "must be at least 1s"))
+	}
+	if renewDeadline <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("renewDeadline"), renewDeadline.String(), "must be greater than zero"))
+	}
+	if retryPeriod <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("retryPeriod"), retryPeriod.String(), "must be greater than zero"))
+	}
+	if renewDeadline >= leaseDuration {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("renewDeadline"), renewDeadline.String(), "must be less than leaseDuration "+leaseDuration.String()))
+	}
+	if jittered := time.Duration(leaderelection.JitterFactor * float64(retryPeriod)); renewDeadline <= jittered {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("renewDeadline"), renewDeadline.String(),
+			"must be greater than retryPeriod*"+strconv.FormatFloat(leaderelection.JitterFactor, 'f', -1, 64)+" "+jittered.String()))
+	}
+
+	if !slices.Contains(ResourceLockTypes(), config.ResourceLock) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("resourceLock"), config.ResourceLock, ResourceLockTypes()))
+	}
+	if config.ResourceName == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("resourceName"), ""))
+	}
+	if config.ResourceNamespace == "" && UsesKubernetes(config.ResourceLock) {
+		allErrs = append(allErrs, field.Required(fldPath.Child("resourceNamespace"), "resource lock "+config.ResourceLock+" lives in a namespace"))
+	}
+	return allErrs
+}
+
+// Validate validates o for the election configured by config.
+func (o *Options) Validate(config *componentbaseconfig.LeaderElectionConfiguration, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if !config.LeaderElect {
+		return allErrs
+	}
+
+	// The zero LossPolicy recampaigns.
+	if err := o.OnLoss.Set(string(o.OnLoss)); err != nil && o.OnLoss != "" {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("onLoss"), o.OnLoss, []LossPolicy{LossPolicyRecampaign, LossPolicyExit, LossPolicyExitError}))
+	}
+	if o.HealthzTimeout <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("healthzTimeout"), o.HealthzTimeout.String(), "must be greater than zero"))
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"priorityStabilizationWindow", o.PriorityStabilizationWindow},
+		{"maxClockSkew", o.MaxClockSkew},
+		{"releaseCoolDown", o.ReleaseCoolDown},
+		{"preconditionGracePeriod", o.PreconditionGracePeriod},
+	} {
+		if d.value < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(d.name), d.value.String(), "must not be negative"))
+		}
+	}
+	if o.Shards < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("shards"), o.Shards, "must not be negative"))
+	}
+	membership := SupportsMembership(config.ResourceLock)
+	if o.Shards > 0 && !membership {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("shards"), "resource lock "+config.ResourceLock+" does not support membership"))
+	}
+	if o.Priority != 0 && !membership {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("priority"), "resource lock "+config.ResourceLock+" does not support membership"))
+	}
+	if o.Priority != 0 && o.Shards > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("priority"), "priorities do not apply to sharded elections"))
+	}
+	if o.UpgradeAware {
+		if !membership {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("upgradeAware"), "resource lock "+config.ResourceLock+" does not support membership"))
+		}
+		if o.Shards > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("upgradeAware"), "rolling updates do not apply to sharded elections"))
+		}
+		if o.BinaryVersion == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("binaryVersion"), "the version of this build is unknown, candidates could not be ordered"))
+		} else if _, err := utilversion.ParseGeneric(o.BinaryVersion); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("binaryVersion"), o.BinaryVersion, err.Error()))
+		}
+	}
+	if o.Coordinated && o.Identity != "" {
+		if err := validateLeaseCandidateName(o.Identity); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("identity"), o.Identity, "must be a valid object name in coordinated leader election"))
+		}
+	}
+	if o.Coordinated && o.BinaryVersion == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("binaryVersion"), "the version of this build is unknown, the API server could not pick a candidate"))
+	}
+	if o.Quorum != nil {
+		if !UsesKubernetes(config.ResourceLock) {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("quorum"), "resource lock "+config.ResourceLock+" does not run on kubernetes"))
+		}
+		if o.Coordinated {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("coordinated"), "coordinated leader election cannot span clusters"))
+		}
+		seen := map[string]bool{}
+		for i, cluster := range o.Quorum.Clusters {
+			if seen[cluster.Name] {
+				allErrs = append(allErrs, field.Duplicate(fldPath.Child("quorum", "clusters").Index(i), cluster.Name))
+			}
+			seen[cluster.Name] = true
+		}
+	}
+	if o.AdvertiseAddress != "" {
+		if _, port, err := net.SplitHostPort(o.AdvertiseAddress); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("advertiseAddress"), o.AdvertiseAddress, err.Error()))
+		} else if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("advertiseAddress"), o.AdvertiseAddress, "port must be a number between 0 and 65535"))
+		}
+	}
+	return allErrs
+}
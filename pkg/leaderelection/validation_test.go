@@ -0,0 +1,100 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package leaderelection
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		lock       string
+		modify     func(o *Options)
+		wantFields []string
+	}{
+		{name: "defaults", lock: LeasesResourceLock, modify: func(o *Options) {}},
+		{name: "priority", lock: LeasesResourceLock, modify: func(o *Options) { o.Priority = 5 }},
+		{
+			name:       "priority without membership",
+			lock:       FileResourceLock,
+			modify:     func(o *Options) { o.Priority = 5 },
+			wantFields: []string{"leaderElection.priority"},
+		},
+		{
+			name:       "priority with shards",
+			lock:       LeasesResourceLock,
+			modify:     func(o *Options) { o.Priority, o.Shards = 5, 4 },
+			wantFields: []string{"leaderElection.priority"},
+		},
+		{
+			name:   "upgrade aware with priority",
+			lock:   LeasesResourceLock,
+			modify: func(o *Options) { o.Priority, o.UpgradeAware = 5, true },
+		},
+		{
+			name:       "upgrade aware with shards",
+			lock:       LeasesResourceLock,
+			modify:     func(o *Options) { o.UpgradeAware, o.Shards = true, 4 },
+			wantFields: []string{"leaderElection.upgradeAware"},
+		},
+		{
+			name:       "upgrade aware without a version",
+			lock:       LeasesResourceLock,
+			modify:     func(o *Options) { o.UpgradeAware, o.BinaryVersion = true, "" },
+			wantFields: []string{"leaderElection.binaryVersion"},
+		},
+		{
+			name:       "negative shards",
+			lock:       LeasesResourceLock,
+			modify:     func(o *Options) { o.Shards = -1 },
+			wantFields: []string{"leaderElection.shards"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &componentbaseconfig.LeaderElectionConfiguration{
+				LeaderElect:       true,
+				ResourceLock:      tt.lock,
+				ResourceName:      "kle",
+				ResourceNamespace: "default",
+			}
+			o := DefaultOptions()
+			o.BinaryVersion = "1.2.3"
+			tt.modify(o)
+
+			errs := o.Validate(config, field.NewPath("leaderElection"))
+			if len(errs) != len(tt.wantFields) {
+				t.Fatalf("Validate() = %v, want errors for %v", errs, tt.wantFields)
+			}
+			for i, err := range errs {
+				if err.Field != tt.wantFields[i] {
+					t.Errorf("Validate() error %d is for %s, want %s", i, err.Field, tt.wantFields[i])
+				}
+			}
+		})
+	}
+}
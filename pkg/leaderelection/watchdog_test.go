@@ -0,0 +1,131 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// client-go reads the time it last renewed in LeaderElector.Check without
+// holding the lock it writes it under, which the race detector reports.
+
+//go:build !race
+
+package leaderelection
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+// wedgedResourceLock is a memory lock whose renewals hang, ignoring their
+// deadline, while wedged is set.
+const wedgedResourceLock = "wedged"
+
+var (
+	wedged   atomic.Bool
+	unwedged = make(chan struct{})
+)
+
+func init() {
+	store := NewMemoryStore()
+	RegisterBackend(wedgedResourceLock, func(namespace, name string, _ clientset.Interface, rlc resourcelock.ResourceLockConfig) (Backend, error) {
+		return &wedgedLock{Backend: NewMemoryBackend(store, namespace, name, rlc)}, nil
+	}, nil)
+}
+
+type wedgedLock struct {
+	Backend
+}
+
+func (wl *wedgedLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	if wedged.Load() {
+		<-unwedged
+	}
+	return wl.Backend.Update(ctx, ler)
+}
+
+// TestWatchDog wedges the renew loop of the leader, and checks that the
+// watchdog reports it unhealthy once its lease has expired.
+func TestWatchDog(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs leader elections")
+	}
+	config := &componentbaseconfig.LeaderElectionConfiguration{
+		LeaderElect:       true,
+		LeaseDuration:     metav1.Duration{Duration: 2 * time.Second},
+		RenewDeadline:     metav1.Duration{Duration: 1500 * time.Millisecond},
+		RetryPeriod:       metav1.Duration{Duration: 100 * time.Millisecond},
+		ResourceLock:      wedgedResourceLock,
+		ResourceName:      "watchdog",
+		ResourceNamespace: "test",
+	}
+	opts := DefaultOptions()
+	opts.Identity = "a"
+	opts.WatchDog = leaderelection.NewLeaderHealthzAdaptor(100 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer func() {
+		close(unwedged)
+		cancel()
+		wg.Wait()
+	}()
+	leading := make(chan struct{}, 1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		run := func(ctx context.Context) {
+			leading <- struct{}{}
+			<-ctx.Done()
+		}
+		if err := NewLeaderElection(run, nil, config, opts, ctx); err != nil {
+			t.Error(err)
+		}
+	}()
+	select {
+	case <-leading:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a did not lead")
+	}
+
+	// Healthy while renewing.
+	for range 5 {
+		if err := opts.WatchDog.Check(nil); err != nil {
+			t.Fatalf("Check() = %v while renewing", err)
+		}
+		time.Sleep(config.RetryPeriod.Duration)
+	}
+
+	wedged.Store(true)
+	wedgedAt := time.Now()
+	waitFor(t, 5*time.Second, func() bool {
+		return opts.WatchDog.Check(nil) != nil
+	})
+	if took := time.Since(wedgedAt); took < config.LeaseDuration.Duration-config.RetryPeriod.Duration {
+		t.Errorf("unhealthy %v after the renew loop wedged, before the lease expired", took)
+	}
+}
@@ -0,0 +1,128 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/yshngg/kle/pkg/leaderelection"
+)
+
+// FencingTokenHeader carries the fencing token of the leader a request is
+// made on behalf of, and FencingLeaseHeader the lease it leads.
+const (
+	FencingTokenHeader = "X-KLE-Fencing-Token"
+	FencingLeaseHeader = "X-KLE-Fencing-Lease"
+)
+
+type fencingRoundTripper struct {
+	next http.RoundTripper
+}
+
+// NewFencingRoundTripper wraps next so that requests made with a
+// leadership context carry its fencing token. A nil next means
+// http.DefaultTransport.
+func NewFencingRoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &fencingRoundTripper{next: next}
+}
+
+func (rt *fencingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, ok := leaderelection.FencingTokenFromContext(req.Context())
+	if !ok {
+		return rt.next.RoundTrip(req)
+	}
+	// A RoundTripper must not modify the request it is given.
+	req = req.Clone(req.Context())
+	req.Header.Set(FencingTokenHeader, token.String())
+	if token.Lease != "" {
+		req.Header.Set(FencingLeaseHeader, token.Lease)
+	}
+	return rt.next.RoundTrip(req)
+}
+
+// FencingGuard turns away requests carrying a fencing token older than the
+// newest it has accepted for the same lease, so that a deposed leader
+// cannot write once its successor has. The tokens of different leases, as
+// told by FencingLeaseHeader, are checked apart. Its zero value is ready to
+// use.
+type FencingGuard struct {
+	// Required also turns away requests without a fencing token.
+	Required bool
+
+	mu     sync.Mutex
+	newest map[string]leaderelection.FencingToken
+}
+
+// WrapHandler wraps the given HTTP handler so that it only serves requests
+// with a current fencing token. Stale tokens are answered with 409
+// Conflict, malformed or missing ones with 400 Bad Request.
+func (g *FencingGuard) WrapHandler(handler http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		v := req.Header.Get(FencingTokenHeader)
+		if v == "" {
+			if g.Required {
+				http.Error(w, fmt.Sprintf("missing %s header", FencingTokenHeader), http.StatusBadRequest)
+				return
+			}
+			handler.ServeHTTP(w, req)
+			return
+		}
+		token, err := leaderelection.ParseFencingToken(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		token.Lease = req.Header.Get(FencingLeaseHeader)
+		if newest, ok := g.accept(token); !ok {
+			http.Error(w, fmt.Sprintf("stale fencing token %s, newest is %s", token, newest), http.StatusConflict)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	}
+}
+
+// Newest returns the newest fencing token accepted for lease, empty for
+// requests that did not name one.
+func (g *FencingGuard) Newest(lease string) leaderelection.FencingToken {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.newest[lease]
+}
+
+func (g *FencingGuard) accept(token leaderelection.FencingToken) (leaderelection.FencingToken, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if newest, ok := g.newest[token.Lease]; ok && token.Less(newest) {
+		return newest, false
+	}
+	if g.newest == nil {
+		g.newest = map[string]leaderelection.FencingToken{}
+	}
+	g.newest[token.Lease] = token
+	return token, true
+}
@@ -0,0 +1,177 @@
+// The MIT License (MIT)
+//
+// Copyright © 2025 Yusheng Guo
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yshngg/kle/pkg/leaderelection"
+)
+
+func TestFencingGuard(t *testing.T) {
+	older := leaderelection.FencingToken{Transitions: 1, Epoch: 100}
+	newer := leaderelection.FencingToken{Transitions: 2, Epoch: 50}
+	ofLease := func(token leaderelection.FencingToken, lease string) leaderelection.FencingToken {
+		token.Lease = lease
+		return token
+	}
+	// request is a request made with token and lease, none if empty, and
+	// the status it is expected to be answered with.
+	type request struct {
+		token      string
+		lease      string
+		wantStatus int
+	}
+	tests := []struct {
+		name       string
+		required   bool
+		requests   []request
+		wantNewest map[string]leaderelection.FencingToken
+	}{
+		{
+			name: "tokens in order",
+			requests: []request{
+				{token: older.String(), wantStatus: http.StatusOK},
+				{token: older.String(), wantStatus: http.StatusOK},
+				{token: newer.String(), wantStatus: http.StatusOK},
+			},
+			wantNewest: map[string]leaderelection.FencingToken{"": newer},
+		},
+		{
+			name: "stale token",
+			requests: []request{
+				{token: newer.String(), wantStatus: http.StatusOK},
+				{token: older.String(), wantStatus: http.StatusConflict},
+				{token: newer.String(), wantStatus: http.StatusOK},
+			},
+			wantNewest: map[string]leaderelection.FencingToken{"": newer},
+		},
+		{
+			name: "two leases",
+			requests: []request{
+				{token: newer.String(), lease: "a", wantStatus: http.StatusOK},
+				{token: older.String(), lease: "b", wantStatus: http.StatusOK},
+				{token: older.String(), lease: "a", wantStatus: http.StatusConflict},
+				{token: older.String(), lease: "b", wantStatus: http.StatusOK},
+				{token: older.String(), wantStatus: http.StatusOK},
+			},
+			wantNewest: map[string]leaderelection.FencingToken{
+				"a": ofLease(newer, "a"),
+				"b": ofLease(older, "b"),
+				"":  older,
+			},
+		},
+		{
+			name: "malformed token",
+			requests: []request{
+				{token: "not-a-token", wantStatus: http.StatusBadRequest},
+			},
+		},
+		{
+			name: "missing token",
+			requests: []request{
+				{wantStatus: http.StatusOK},
+				{token: older.String(), wantStatus: http.StatusOK},
+				{wantStatus: http.StatusOK},
+			},
+			wantNewest: map[string]leaderelection.FencingToken{"": older},
+		},
+		{
+			name:     "missing token required",
+			required: true,
+			requests: []request{
+				{wantStatus: http.StatusBadRequest},
+				{token: older.String(), wantStatus: http.StatusOK},
+			},
+			wantNewest: map[string]leaderelection.FencingToken{"": older},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := &FencingGuard{Required: tt.required}
+			handler := guard.WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			for i, r := range tt.requests {
+				req := httptest.NewRequest(http.MethodPost, "/write", nil)
+				if r.token != "" {
+					req.Header.Set(FencingTokenHeader, r.token)
+				}
+				if r.lease != "" {
+					req.Header.Set(FencingLeaseHeader, r.lease)
+				}
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				if rec.Code != r.wantStatus {
+					t.Errorf("request %d with token %q: got status %d, want %d", i, r.token, rec.Code, r.wantStatus)
+				}
+			}
+			for lease, want := range tt.wantNewest {
+				if got := guard.Newest(lease); got != want {
+					t.Errorf("Newest(%q) = %s, want %s", lease, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFencingRoundTripper(t *testing.T) {
+	token := leaderelection.FencingToken{Lease: "kle", Transitions: 3, Epoch: 1700000000}
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		want      string
+		wantLease string
+	}{
+		{name: "leadership context", ctx: leaderelection.WithFencingToken(context.Background(), token), want: token.String(), wantLease: "kle"},
+		{name: "other context", ctx: context.Background()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, gotLease string
+			srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+				got, gotLease = req.Header.Get(FencingTokenHeader), req.Header.Get(FencingLeaseHeader)
+			}))
+			defer srv.Close()
+
+			req, err := http.NewRequestWithContext(tt.ctx, http.MethodGet, srv.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := NewFencingRoundTripper(nil).RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			if got != tt.want || gotLease != tt.wantLease {
+				t.Errorf("got headers %q and %q, want %q and %q", got, gotLease, tt.want, tt.wantLease)
+			}
+			if req.Header.Get(FencingTokenHeader) != "" {
+				t.Error("the request given was modified")
+			}
+		})
+	}
+}
@@ -27,6 +27,8 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
+
+	utilversion "k8s.io/apimachinery/pkg/util/version"
 )
 
 var (
@@ -102,3 +104,18 @@ func splitVersion(version string) (string, string) {
 	// Something went wrong
 	return "", ""
 }
+
+// SemVer returns the semantic version of the build, for example 0.18.0, or
+// an empty string when it is not known. Versions described by git, such as
+// v0.18.0-46-g939c1c0, are reduced to the tag they follow, so only tagged
+// builds are ordered: builds on top of the same tag compare equal.
+func (i Info) SemVer() string {
+	if i.Major != "" && i.Minor != "" {
+		return i.Major + "." + i.Minor
+	}
+	v, err := utilversion.ParseGeneric(i.GitVersion)
+	if err != nil {
+		return ""
+	}
+	return v.String()
+}